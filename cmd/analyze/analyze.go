@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"time"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func main() {
+	err := mainCmd().Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+const (
+	flagDepth   = "depth"
+	flagTime    = "time"
+	flagMultiPV = "multipv"
+)
+
+func mainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `analyze [moves...]`,
+		Short: `Prints the score of every legal move in a position.`,
+		Long: `Prints the score of every legal move in a position.
+
+The position is given by the moves played from the start of the game, as
+"row col" pairs, either as separate arguments or as one string, for example
+"analyze 4 4 3 3" or "analyze '4 4 3 3'". The player to move is drawn as X, and
+scores are for X.
+
+Every legal move is searched to depth, or with a time limit, to the deepest
+depth completed in time. Moves are printed best first with their score and
+whether they win a board or let the opponent win one, and the best multipv
+moves with the line the search expects.`,
+		RunE: runCmd,
+	}
+
+	cmd.Flags().Int(flagDepth, 4, "the depth to search to, or the most depth with a time limit")
+	cmd.Flags().Duration(flagTime, 0, "the time to search for, or to depth if zero")
+	cmd.Flags().Int(flagMultiPV, 1, "the number of best moves to print lines for, or every move if zero")
+
+	return cmd
+}
+
+func runCmd(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	moves, err := ttt.ParseMoves(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	game, legal, err := ttt.Position(moves)
+	if err != nil {
+		return err
+	}
+
+	maxDepth, err := cmd.Flags().GetInt(flagDepth)
+	if err != nil {
+		return err
+	}
+	if maxDepth < 1 {
+		return errors.New("depth must be positive")
+	}
+	limit, err := cmd.Flags().GetDuration(flagTime)
+	if err != nil {
+		return err
+	}
+	multiPV, err := cmd.Flags().GetInt(flagMultiPV)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(game)
+
+	start := time.Now()
+	s := ttt.NewSearcher()
+	var analyses []ttt.MoveAnalysis
+	depth := maxDepth
+	if limit == 0 {
+		analyses = s.Analyze(legal, game, depth, multiPV)
+	} else {
+		// Deepen until the time runs out, keeping the last complete search.
+		s.Deadline = start.Add(limit)
+		depth = 0
+		for d := 1; d <= maxDepth; d++ {
+			next := s.Analyze(legal, game, d, multiPV)
+			if s.Stopped() {
+				break
+			}
+			analyses, depth = next, d
+			if time.Now().After(s.Deadline) {
+				break
+			}
+		}
+		if analyses == nil {
+			return errors.New("no search completed in time")
+		}
+	}
+
+	fmt.Printf("depth %d, %d nodes, %v\n\n", depth, s.Nodes(), time.Since(start).Round(time.Millisecond))
+	for i, analysis := range analyses {
+		line := fmt.Sprintf("%2d. %s  %8s  %-11s  %s", i+1, analysis.Move, formatScore(analysis.Score),
+			notes(analysis), ttt.FormatMoves(analysis.Line))
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+
+	return nil
+}
+
+// notes describes what a move does to the boards.
+func notes(analysis ttt.MoveAnalysis) string {
+	switch {
+	case analysis.WinsGame:
+		return "wins game"
+	case analysis.WinsBoard && analysis.GivesBoard:
+		return "wins, gives"
+	case analysis.WinsBoard:
+		return "wins board"
+	case analysis.GivesBoard:
+		return "gives board"
+	default:
+		return ""
+	}
+}
+
+func formatScore(score float64) string {
+	return fmt.Sprintf("%+.2f", score)
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"github.com/spf13/cobra"
+	"math/rand/v2"
 	"os"
-	"ultimate-tic-tac-toe/pkg/ttt"
+	"text/tabwriter"
+	"time"
+	"ultimate-tic-tac-toe/pkg/battle"
 )
 
 func main() {
@@ -13,49 +18,202 @@ func main() {
 	}
 }
 
+const (
+	flagSelf        = "self"
+	flagOpponent    = "opponent"
+	flagGames       = "games"
+	flagRandomPlies = "random-plies"
+	flagOpenings    = "openings"
+	flagSeed        = "seed"
+	flagTime        = "time"
+	flagOut         = "out"
+)
+
+// maxRandomPlies is the most random plies an opening may have. Nearly every
+// game is still open after 40 random plies, but fewer than half after 60.
+const maxRandomPlies = 40
+
 func mainCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   `battle`,
 		Short: `Battles ultimate tic-tac-toe opponents.`,
-		RunE:  runCmd,
+		Long: `Battles ultimate tic-tac-toe opponents.
+
+Agents are specified as name[:key=value,...], for example "random" or
+"minimax:depth=4".
+
+Each opening is played twice, once with each agent moving first. Openings are
+either read from a file with one move list of "row col" pairs per line, or
+generated by playing random legal moves. Without random plies, two agents which
+always choose the same move play the same game every time.
+
+Time controls are one of "100ms" per move, "1000ms/100ms" for the first move
+and the rest, "60s+1s" for the whole game plus an increment per move, or
+"codingame". Agents which exceed their time lose.`,
+		RunE: runCmd,
 	}
 
+	cmd.Flags().String(flagSelf, "minimax:depth=4", "the agent to evaluate")
+	cmd.Flags().String(flagOpponent, "random", "the agent to evaluate against")
+	addMatchFlags(cmd)
+
+	cmd.AddCommand(tournamentCmd())
+	cmd.AddCommand(replayCmd())
+	cmd.AddCommand(reviewCmd())
+	cmd.AddCommand(sweepCmd())
+	cmd.AddCommand(tuneCmd())
+	cmd.AddCommand(texelCmd())
+
 	return cmd
 }
 
+func addMatchFlags(cmd *cobra.Command) {
+	cmd.Flags().IntP(flagGames, "n", 100, "the number of games to play per pairing if openings are generated")
+	cmd.Flags().Int(flagRandomPlies, 4, "the number of random plies in generated openings")
+	cmd.Flags().String(flagOpenings, "", "a file of openings to play instead of generating them")
+	cmd.Flags().Uint64(flagSeed, 1, "the seed for opening generation and random agents")
+	cmd.Flags().String(flagTime, "", "the time control, or none if empty")
+	cmd.Flags().String(flagOut, "", "a file to write the record of every game to")
+}
+
 func runCmd(cmd *cobra.Command, _ []string) error {
 	cmd.SilenceUsage = true
 
-	return nil
+	seed, err := cmd.Flags().GetUint64(flagSeed)
+	if err != nil {
+		return err
+	}
+
+	self, err := parseAgentFlag(cmd, flagSelf, seed)
+	if err != nil {
+		return err
+	}
+	opponent, err := parseAgentFlag(cmd, flagOpponent, seed+1)
+	if err != nil {
+		return err
+	}
+
+	openings, err := openingsFromFlags(cmd, seed)
+	if err != nil {
+		return err
+	}
+
+	tc, err := timeControlFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	result, records := battle.Battle(self, opponent, openings, tc)
+	fmt.Printf("%s vs %s: %s, score %.3f, Elo %+.0f\n",
+		self.Name(), opponent.Name(), result, result.Score(), battle.EloDifference(result.Score()))
+	fmt.Println()
+
+	err = printTimings([]battle.Agent{self, opponent}, records)
+	if err != nil {
+		return err
+	}
+
+	return writeRecordsFromFlags(cmd, records, seed)
 }
 
-// Battle runs n battles between self and opponent, returning the proportion of wins
-// self had.
-func Battle(self, opponent any, n int) float64 {
-	return 0.0
+func writeRecordsFromFlags(cmd *cobra.Command, records []*battle.Record, seed uint64) error {
+	path, err := cmd.Flags().GetString(flagOut)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return nil
+	}
+
+	for _, record := range records {
+		record.Seed = seed
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = battle.WriteRecords(f, records)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing records to %q: %w", path, err)
+	}
+
+	return f.Close()
 }
 
-// battle runs a battle between self and opponent.
-// Returns 1.0 if self won, 0.0 if opponent, and 0.5 if a tie.
-func battle(self, opponent any) float64 {
-	_ = &ttt.Game{
-		Boards:  [3][3]*ttt.Board{{{}, {}, {}}, {{}, {}, {}}, {{}, {}, {}}},
-		Winners: &ttt.Board{},
+func timeControlFromFlags(cmd *cobra.Command) (battle.TimeControl, error) {
+	s, err := cmd.Flags().GetString(flagTime)
+	if err != nil {
+		return battle.TimeControl{}, err
+	}
+
+	return battle.ParseTimeControl(s)
+}
+
+func printTimings(agents []battle.Agent, records []*battle.Record) error {
+	timings := battle.Timings(records, len(agents))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "agent\tmoves\tmean\tmax\tmax/limit\tclose\ttimeouts\t")
+	for i, agent := range agents {
+		t := &timings[i]
+		fmt.Fprintf(w, "%d %s\t%d\t%v\t%v\t%.0f%%\t%d\t%d\t\n",
+			i+1, agent.Name(), t.Moves, t.Mean().Round(time.Microsecond), t.Max.Round(time.Microsecond), 100*t.MaxFraction, t.Close, t.Timeouts)
+	}
+
+	return w.Flush()
+}
+
+func parseAgentFlag(cmd *cobra.Command, flag string, seed uint64) (battle.Agent, error) {
+	spec, err := cmd.Flags().GetString(flag)
+	if err != nil {
+		return nil, err
 	}
 
-	nMoves := 81
-	moves := make([]ttt.Move, nMoves)
-	i := 0
-	for a := uint8(0); a < 3; a++ {
-		for b := uint8(0); b < 3; b++ {
-			for x := uint8(0); x < 3; x++ {
-				for y := uint8(0); y < 3; y++ {
-					moves[i] = ttt.ToMove(a, b, x, y)
-					i++
-				}
-			}
+	return battle.ParseAgent(spec, seed)
+}
+
+func openingsFromFlags(cmd *cobra.Command, seed uint64) ([]battle.Opening, error) {
+	path, err := cmd.Flags().GetString(flagOpenings)
+	if err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		openings, err := battle.ReadOpenings(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading openings from %q: %w", path, err)
+		}
+		if len(openings) == 0 {
+			return nil, fmt.Errorf("no openings in %q", path)
 		}
+		return openings, nil
+	}
+
+	games, err := cmd.Flags().GetInt(flagGames)
+	if err != nil {
+		return nil, err
+	}
+	if games < 2 || games%2 != 0 {
+		return nil, errors.New("number of games must be a positive even number")
+	}
+
+	plies, err := cmd.Flags().GetInt(flagRandomPlies)
+	if err != nil {
+		return nil, err
+	}
+	if plies < 0 || plies > maxRandomPlies {
+		return nil, fmt.Errorf("random plies must be from 0 to %d", maxRandomPlies)
 	}
 
-	return 0.0
+	rng := rand.New(rand.NewPCG(seed, 0))
+	return battle.RandomOpenings(rng, games/2, plies)
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"strconv"
+	"strings"
+	"ultimate-tic-tac-toe/pkg/battle"
+)
+
+const (
+	flagNoWait = "no-wait"
+)
+
+func replayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `replay file [game]`,
+		Short: `Steps through a game saved with --out.`,
+		Long: `Steps through a game saved with --out.
+
+game is the index of the game in the file, counting from zero. Press enter or
+"n" to step forward, "p" to step back, and "q" to quit. The result is printed
+after the last move or on quitting. The agent which moved first is drawn as X.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runReplay,
+	}
+
+	cmd.Flags().Bool(flagNoWait, false, "print every position without waiting for input")
+
+	return cmd
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	records, err := readRecordsFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	index := 0
+	if len(args) == 2 {
+		index, err = strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("parsing game index: %w", err)
+		}
+	}
+	if index < 0 || index >= len(records) {
+		return fmt.Errorf("game %d out of range, %q has %d games", index, args[0], len(records))
+	}
+
+	noWait, err := cmd.Flags().GetBool(flagNoWait)
+	if err != nil {
+		return err
+	}
+
+	record := records[index]
+	fmt.Printf("X: %s\nO: %s\nopening %d, seed %d\n\n", record.Agents[0], record.Agents[1], record.Opening, record.Seed)
+
+	// An illegal move can't be applied to the game, so stop before it.
+	lastPly := len(record.Moves)
+	if record.Termination == battle.Illegal {
+		lastPly--
+	}
+
+	input := bufio.NewScanner(os.Stdin)
+	// Stepping past the last ply ends the replay, as does quitting.
+replay:
+	for ply := 0; ply <= lastPly; {
+		printPly(record, ply)
+
+		if noWait {
+			ply++
+			continue
+		}
+
+		fmt.Print("[n]ext, [p]revious, [q]uit: ")
+		if !input.Scan() {
+			break
+		}
+		switch strings.TrimSpace(input.Text()) {
+		case "", "n":
+			ply++
+		case "p":
+			ply = max(ply-1, 0)
+		case "q":
+			break replay
+		}
+	}
+
+	fmt.Printf("%s by %s\n", record.Score(), record.Termination)
+	if record.Termination == battle.Illegal {
+		fmt.Println(record.Game(lastPly).Validate(record.Moves[lastPly]))
+	}
+
+	return nil
+}
+
+func printPly(record *battle.Record, ply int) {
+	switch {
+	case ply == 0:
+		fmt.Println("start")
+	case ply <= record.OpeningPlies:
+		fmt.Printf("%d. %c %v (opening)\n", ply, "XO"[(ply-1)%2], record.Moves[ply-1])
+	case ply-1-record.OpeningPlies < len(record.Times):
+		fmt.Printf("%d. %c %v (%.1fms)\n", ply, "XO"[(ply-1)%2], record.Moves[ply-1],
+			float64(record.Times[ply-1-record.OpeningPlies].Microseconds())/1000)
+	default:
+		// Records written by hand or by older versions may lack times.
+		fmt.Printf("%d. %c %v\n", ply, "XO"[(ply-1)%2], record.Moves[ply-1])
+	}
+
+	fmt.Println(record.Game(ply))
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"strconv"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+const (
+	flagDepth      = "depth"
+	flagInaccuracy = "inaccuracy"
+	flagMistake    = "mistake"
+	flagBlunder    = "blunder"
+)
+
+func reviewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `review file [game]`,
+		Short: `Annotates a game saved with --out, marking mistakes.`,
+		Long: `Annotates a game saved with --out, marking mistakes.
+
+game is the index of the game in the file, counting from zero. Every position
+after the opening is searched to depth, and each move is judged by how far its
+score falls short of the best move's: an inaccuracy, a mistake or a blunder,
+at the thresholds given in evaluation units. Missing a forced win, or walking
+into a forced loss, is a blunder. Scores are for the player who moved.
+
+The game was decided at the move from which the winner kept a lead of at
+least the blunder threshold to the end.
+
+The review is written as text, with the better move and its line for each
+inaccuracy, mistake and blunder, or as JSON.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runReview,
+	}
+
+	cmd.Flags().Int(flagDepth, 4, "the depth to search each position to")
+	cmd.Flags().Float64(flagInaccuracy, battle.DefaultThresholds.Inaccuracy, "the least drop in score for an inaccuracy")
+	cmd.Flags().Float64(flagMistake, battle.DefaultThresholds.Mistake, "the least drop in score for a mistake")
+	cmd.Flags().Float64(flagBlunder, battle.DefaultThresholds.Blunder, "the least drop in score for a blunder")
+	cmd.Flags().String(flagFormat, "text", "the format to write, text or json")
+	cmd.Flags().String(flagOut, "", "the file to write the review to, or stdout if empty")
+
+	return cmd
+}
+
+func runReview(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	records, err := readRecordsFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	index := 0
+	if len(args) == 2 {
+		index, err = strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("parsing game index: %w", err)
+		}
+	}
+	if index < 0 || index >= len(records) {
+		return fmt.Errorf("game %d out of range, %q has %d games", index, args[0], len(records))
+	}
+
+	depth, err := cmd.Flags().GetInt(flagDepth)
+	if err != nil {
+		return err
+	}
+	if depth < 1 {
+		return errors.New("depth must be positive")
+	}
+	var thresholds battle.Thresholds
+	thresholds.Inaccuracy, err = cmd.Flags().GetFloat64(flagInaccuracy)
+	if err != nil {
+		return err
+	}
+	thresholds.Mistake, err = cmd.Flags().GetFloat64(flagMistake)
+	if err != nil {
+		return err
+	}
+	thresholds.Blunder, err = cmd.Flags().GetFloat64(flagBlunder)
+	if err != nil {
+		return err
+	}
+	if thresholds.Inaccuracy > thresholds.Mistake || thresholds.Mistake > thresholds.Blunder {
+		return errors.New("thresholds must increase from inaccuracy to mistake to blunder")
+	}
+
+	review := battle.ReviewGame(ttt.NewSearcher(), records[index], depth, thresholds)
+
+	format, err := cmd.Flags().GetString(flagFormat)
+	if err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	switch format {
+	case "text":
+		writeReviewText(buf, review)
+	case "json":
+		err = json.NewEncoder(buf).Encode(review)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	path, err := cmd.Flags().GetString(flagOut)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func writeReviewText(buf *bytes.Buffer, review *battle.Review) {
+	record := review.Record
+	_, _ = fmt.Fprintf(buf, "X: %s\nO: %s\nopening %d, seed %d, depth %d\n\n",
+		record.Agents[0], record.Agents[1], record.Opening, record.Seed, review.Depth)
+
+	for ply, move := range record.Moves[:record.OpeningPlies] {
+		_, _ = fmt.Fprintf(buf, "%d. %c %v (opening)\n", ply+1, "XO"[ply%2], move)
+	}
+
+	counts := make(map[battle.Judgement][2]int)
+	for _, m := range review.Moves {
+		mover := m.Ply % 2
+		_, _ = fmt.Fprintf(buf, "%d. %c %v  %+.1f", m.Ply+1, "XO"[mover], m.Move, m.Score)
+		if m.Judgement != battle.Best {
+			_, _ = fmt.Fprintf(buf, "  %s, better %v %+.1f: %s",
+				m.Judgement, m.BestMove, m.BestScore, ttt.FormatMoves(m.BestLine))
+		}
+		if m.Ply == review.Decided {
+			_, _ = fmt.Fprint(buf, "  (decided)")
+		}
+		buf.WriteByte('\n')
+
+		c := counts[m.Judgement]
+		c[mover]++
+		counts[m.Judgement] = c
+	}
+	if record.Termination == battle.Illegal && len(record.Moves) > 0 {
+		ply := len(record.Moves) - 1
+		_, _ = fmt.Fprintf(buf, "%d. %c %v  illegal\n", ply+1, "XO"[ply%2], record.Moves[ply])
+	}
+
+	_, _ = fmt.Fprintf(buf, "\n%s by %s\n", record.Score(), record.Termination)
+	for _, j := range []battle.Judgement{battle.Inaccuracy, battle.Mistake, battle.Blunder} {
+		_, _ = fmt.Fprintf(buf, "%s: X %d, O %d\n", j, counts[j][0], counts[j][1])
+	}
+}
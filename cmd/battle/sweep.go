@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"strconv"
+	"ultimate-tic-tac-toe/pkg/battle"
+)
+
+const (
+	flagAgent    = "agent"
+	flagBaseline = "baseline"
+	flagFormat   = "format"
+)
+
+func sweepCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `sweep axis...`,
+		Short: `Battles an agent with each combination of parameters against a baseline.`,
+		Long: `Battles an agent with each combination of parameters against a baseline.
+
+Each axis is a parameter and the values to try, for example "depth=2,3,4" or
+"meta=50,100,200". Every combination of values is set on --agent, replacing
+values it already has, and played against --baseline.
+
+Writes a table of the result, score and Elo difference from the baseline of
+each combination as CSV or JSON.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runSweep,
+	}
+
+	cmd.Flags().String(flagAgent, "minimax", "the agent to set parameters on")
+	cmd.Flags().String(flagBaseline, "minimax:depth=4", "the agent to play against")
+	cmd.Flags().String(flagFormat, "csv", "the format of the table, csv or json")
+	addMatchFlags(cmd)
+
+	return cmd
+}
+
+func runSweep(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	axes := make([]battle.Axis, len(args))
+	for i, arg := range args {
+		var err error
+		axes[i], err = battle.ParseAxis(arg)
+		if err != nil {
+			return err
+		}
+	}
+
+	format, err := cmd.Flags().GetString(flagFormat)
+	if err != nil {
+		return err
+	}
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	seed, err := cmd.Flags().GetUint64(flagSeed)
+	if err != nil {
+		return err
+	}
+
+	spec, err := cmd.Flags().GetString(flagAgent)
+	if err != nil {
+		return err
+	}
+
+	baseline, err := parseAgentFlag(cmd, flagBaseline, seed+1)
+	if err != nil {
+		return err
+	}
+
+	openings, err := openingsFromFlags(cmd, seed)
+	if err != nil {
+		return err
+	}
+
+	tc, err := timeControlFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	results, records, err := battle.Sweep(spec, axes, seed, baseline, openings, tc)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		err = writeSweepJSON(axes, results)
+	default:
+		err = writeSweepCSV(axes, results)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeRecordsFromFlags(cmd, records, seed)
+}
+
+func writeSweepCSV(axes []battle.Axis, results []battle.SweepResult) error {
+	w := csv.NewWriter(os.Stdout)
+
+	var header []string
+	for _, axis := range axes {
+		header = append(header, axis.Key)
+	}
+	header = append(header, "agent", "wins", "draws", "losses", "score", "elo")
+	err := w.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		row := append([]string{}, result.Values...)
+		row = append(row,
+			result.Agent,
+			strconv.Itoa(result.Result.Wins),
+			strconv.Itoa(result.Result.Draws),
+			strconv.Itoa(result.Result.Losses),
+			strconv.FormatFloat(result.Result.Score(), 'f', 4, 64),
+			strconv.FormatFloat(battle.EloDifference(result.Result.Score()), 'f', 1, 64),
+		)
+		err = w.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+type sweepRow struct {
+	Params map[string]string `json:"params"`
+	Agent  string            `json:"agent"`
+	Wins   int               `json:"wins"`
+	Draws  int               `json:"draws"`
+	Losses int               `json:"losses"`
+	Score  float64           `json:"score"`
+	Elo    float64           `json:"elo"`
+}
+
+func writeSweepJSON(axes []battle.Axis, results []battle.SweepResult) error {
+	rows := make([]sweepRow, len(results))
+	for i, result := range results {
+		params := make(map[string]string)
+		for j, axis := range axes {
+			params[axis.Key] = result.Values[j]
+		}
+
+		rows[i] = sweepRow{
+			Params: params,
+			Agent:  result.Agent,
+			Wins:   result.Result.Wins,
+			Draws:  result.Result.Draws,
+			Losses: result.Result.Losses,
+			Score:  result.Result.Score(),
+			Elo:    battle.EloDifference(result.Result.Score()),
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
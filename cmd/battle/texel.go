@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+const (
+	flagStart = "start"
+)
+
+func texelCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `texel records...`,
+		Short: `Fits evaluation weights to the results of recorded games.`,
+		Long: `Fits evaluation weights to the results of recorded games.
+
+Reads game records written with --out, labels every position with the result
+of its game, and fits the weights of the evaluation features by minimizing the
+logistic loss between evaluation and result.
+
+The weights are written as JSON, which the minimax agent loads with
+"minimax:weights=file".`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runTexel,
+	}
+
+	cmd.Flags().String(flagStart, "", "a weights file to start from instead of the defaults")
+	cmd.Flags().Int(flagIterations, 1000, "the number of steps of gradient descent")
+	cmd.Flags().Float64(flagLearningRate, 0.5, "the scale of each step")
+	cmd.Flags().String(flagOut, "", "the file to write weights to, or stdout if empty")
+
+	return cmd
+}
+
+func runTexel(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	var records []*battle.Record
+	for _, path := range args {
+		fileRecords, err := readRecordsFile(path)
+		if err != nil {
+			return err
+		}
+		records = append(records, fileRecords...)
+	}
+
+	positions := battle.Positions(records)
+	if len(positions) == 0 {
+		return fmt.Errorf("no positions in %d games", len(records))
+	}
+
+	weights := ttt.DefaultWeights
+	start, err := cmd.Flags().GetString(flagStart)
+	if err != nil {
+		return err
+	}
+	if start != "" {
+		weights, err = ttt.LoadWeights(start)
+		if err != nil {
+			return err
+		}
+	}
+
+	iterations, err := cmd.Flags().GetInt(flagIterations)
+	if err != nil {
+		return err
+	}
+
+	texel := battle.NewTexel(weights)
+	texel.LearningRate, err = cmd.Flags().GetFloat64(flagLearningRate)
+	if err != nil {
+		return err
+	}
+
+	texel.FitK(positions)
+	_, _ = fmt.Fprintf(os.Stderr, "%d positions from %d games, K=%.4g, loss %.6f\n",
+		len(positions), len(records), texel.K, texel.Loss(positions))
+
+	for i := 0; i < iterations; i++ {
+		loss := texel.Step(positions)
+		if (i+1)%100 == 0 {
+			_, _ = fmt.Fprintf(os.Stderr, "iteration %d: loss %.6f\n", i+1, loss)
+		}
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "final loss %.6f\n", texel.Loss(positions))
+
+	out, err := cmd.Flags().GetString(flagOut)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		return ttt.WriteWeights(os.Stdout, texel.Weights)
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	err = ttt.WriteWeights(f, texel.Weights)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readRecordsFile(path string) ([]*battle.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := battle.ReadRecords(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading records from %q: %w", path, err)
+	}
+	return records, nil
+}
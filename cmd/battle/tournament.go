@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"ultimate-tic-tac-toe/pkg/battle"
+)
+
+const (
+	flagGauntlet = "gauntlet"
+)
+
+func tournamentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `tournament agent agent...`,
+		Short: `Ranks agents by playing a tournament between them.`,
+		Long: `Ranks agents by playing a tournament between them.
+
+By default every agent plays every other agent. With --gauntlet, the first
+agent plays each of the others.
+
+Prints a crosstable of results and a rating table with Elo fit jointly over
+all games.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: runTournament,
+	}
+
+	cmd.Flags().Bool(flagGauntlet, false, "play the first agent against each of the others")
+	addMatchFlags(cmd)
+
+	return cmd
+}
+
+func runTournament(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	seed, err := cmd.Flags().GetUint64(flagSeed)
+	if err != nil {
+		return err
+	}
+
+	agents := make([]battle.Agent, len(args))
+	for i, spec := range args {
+		agents[i], err = battle.ParseAgent(spec, seed+uint64(i)+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	openings, err := openingsFromFlags(cmd, seed)
+	if err != nil {
+		return err
+	}
+
+	gauntlet, err := cmd.Flags().GetBool(flagGauntlet)
+	if err != nil {
+		return err
+	}
+
+	pairings := battle.RoundRobin(len(agents))
+	if gauntlet {
+		pairings = battle.Gauntlet(len(agents))
+	}
+
+	tc, err := timeControlFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	table, records := battle.Tournament(agents, pairings, openings, tc)
+
+	err = printCrosstable(agents, table)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	err = printRatings(agents, table)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	err = printTimings(agents, records)
+	if err != nil {
+		return err
+	}
+
+	return writeRecordsFromFlags(cmd, records, seed)
+}
+
+func printCrosstable(agents []battle.Agent, table battle.Crosstable) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprint(w, "\t")
+	for j := range agents {
+		fmt.Fprintf(w, "%d\t", j+1)
+	}
+	fmt.Fprintln(w, "\t")
+
+	for i, agent := range agents {
+		fmt.Fprintf(w, "%d %s\t", i+1, agent.Name())
+		for j := range agents {
+			switch {
+			case i == j:
+				fmt.Fprint(w, "-\t")
+			case table[i][j].Games() == 0:
+				fmt.Fprint(w, "\t")
+			default:
+				fmt.Fprintf(w, "%.1f/%d\t", table[i][j].Points(), table[i][j].Games())
+			}
+		}
+		fmt.Fprintf(w, "%.1f/%d\t\n", table.Total(i).Points(), table.Total(i).Games())
+	}
+
+	return w.Flush()
+}
+
+func printRatings(agents []battle.Agent, table battle.Crosstable) error {
+	ratings := battle.FitElo(table)
+
+	order := make([]int, len(agents))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		switch {
+		case ratings[i] > ratings[j]:
+			return -1
+		case ratings[i] < ratings[j]:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "rank\tagent\tElo\tgames\tscore\t")
+	for rank, i := range order {
+		total := table.Total(i)
+		fmt.Fprintf(w, "%d\t%s\t%+.0f\t%d\t%.1f%%\t\n", rank+1, agents[i].Name(), ratings[i], total.Games(), 100*total.Score())
+	}
+
+	return w.Flush()
+}
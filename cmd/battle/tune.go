@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io/fs"
+	"strings"
+	"ultimate-tic-tac-toe/pkg/battle"
+)
+
+const (
+	flagCheckpoint   = "checkpoint"
+	flagIterations   = "iterations"
+	flagPairs        = "pairs"
+	flagLearningRate = "learning-rate"
+)
+
+// defaultSPSAParams are the evaluation weights of the minimax agent.
+var defaultSPSAParams = []string{
+	"meta=100:20",
+	"board=1:0.5",
+	"boardwin=1:0.5",
+	"floor=-100:20",
+	"sendfree=2:1",
+	"sendboard=10:5",
+	"sendgame=100:20",
+}
+
+func tuneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `tune [param...]`,
+		Short: `Tunes agent parameters with SPSA self-play.`,
+		Long: `Tunes agent parameters with SPSA self-play.
+
+Each param is of the form key=value:c or key=value:c:min:max, where value is
+the starting value and c the size of perturbations in the first iteration.
+Defaults to the evaluation weights of the minimax agent:
+
+  ` + strings.Join(defaultSPSAParams, " ") + `
+
+Progress is saved to --checkpoint after every iteration. If the checkpoint
+exists, tuning resumes from it and the other settings are ignored.`,
+		RunE: runTune,
+	}
+
+	cmd.Flags().String(flagCheckpoint, "spsa.json", "the file to save progress to and resume from")
+	cmd.Flags().Int(flagIterations, 100, "the number of iterations to run")
+	cmd.Flags().String(flagAgent, "minimax:depth=3", "the agent to set parameters on")
+	cmd.Flags().Int(flagPairs, 8, "the number of openings, each played twice, per iteration")
+	cmd.Flags().Int(flagRandomPlies, 4, "the number of random plies in each opening")
+	cmd.Flags().Float64(flagLearningRate, 1.0, "the scale of parameter updates")
+	cmd.Flags().String(flagTime, "", "the time control, or none if empty")
+	cmd.Flags().Uint64(flagSeed, 1, "the seed for openings and perturbations")
+
+	return cmd
+}
+
+func runTune(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	path, err := cmd.Flags().GetString(flagCheckpoint)
+	if err != nil {
+		return err
+	}
+
+	iterations, err := cmd.Flags().GetInt(flagIterations)
+	if err != nil {
+		return err
+	}
+
+	s, err := battle.LoadSPSA(path)
+	switch {
+	case err == nil:
+		if len(args) > 0 {
+			return fmt.Errorf("resuming from %q, which already has parameters", path)
+		}
+		fmt.Printf("resuming from %q after %d iterations\n", path, s.Iteration)
+	case errors.Is(err, fs.ErrNotExist):
+		s, err = newSPSAFromFlags(cmd, args)
+		if err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	for i := 0; i < iterations; i++ {
+		err = s.Step()
+		if err != nil {
+			return err
+		}
+
+		err = s.Save(path)
+		if err != nil {
+			return fmt.Errorf("saving checkpoint: %w", err)
+		}
+
+		step := s.History[len(s.History)-1]
+		fmt.Printf("iteration %d: %v", step.Iteration, step.Plus)
+		for j, p := range s.Params {
+			fmt.Printf(" %s=%.4g", p.Key, step.Values[j])
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func newSPSAFromFlags(cmd *cobra.Command, args []string) (*battle.SPSA, error) {
+	if len(args) == 0 {
+		args = defaultSPSAParams
+	}
+
+	params := make([]battle.SPSAParam, len(args))
+	for i, arg := range args {
+		var err error
+		params[i], err = battle.ParseSPSAParam(arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	spec, err := cmd.Flags().GetString(flagAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	s := battle.NewSPSA(spec, params)
+
+	s.Pairs, err = cmd.Flags().GetInt(flagPairs)
+	if err != nil {
+		return nil, err
+	}
+	if s.Pairs < 1 {
+		return nil, errors.New("pairs must be positive")
+	}
+	s.Plies, err = cmd.Flags().GetInt(flagRandomPlies)
+	if err != nil {
+		return nil, err
+	}
+	if s.Plies < 0 || s.Plies > maxRandomPlies {
+		return nil, fmt.Errorf("random plies must be from 0 to %d", maxRandomPlies)
+	}
+	s.LearningRate, err = cmd.Flags().GetFloat64(flagLearningRate)
+	if err != nil {
+		return nil, err
+	}
+	s.TimeControl, err = cmd.Flags().GetString(flagTime)
+	if err != nil {
+		return nil, err
+	}
+	s.Seed, err = cmd.Flags().GetUint64(flagSeed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fail now rather than after the first iteration if the settings are invalid.
+	_, err = battle.ParseTimeControl(s.TimeControl)
+	if err != nil {
+		return nil, err
+	}
+	_, err = battle.ParseAgent(spec, s.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
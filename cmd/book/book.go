@@ -0,0 +1,231 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func main() {
+	err := mainCmd().Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+const (
+	flagPlayer      = "player"
+	flagGames       = "games"
+	flagRandomPlies = "random-plies"
+	flagSeed        = "seed"
+	flagAgent       = "agent"
+	flagTime        = "time"
+	flagPlies       = "plies"
+	flagMinCount    = "min-count"
+	flagBook        = "book"
+	flagSymmetric   = "symmetric"
+	flagFormat      = "format"
+	flagOut         = "out"
+)
+
+func mainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `book [records...]`,
+		Short: `Builds an opening book from the positions reached in games.`,
+		Long: `Builds an opening book from the positions reached in games.
+
+Counts the positions reached in the first plies of recorded games, or if no
+records are given, of games the player agent plays against itself after random
+openings. Every position reached at least min-count times which is not already
+in the book is searched by the agent, and its choice added to the book.
+
+With --symmetric, positions which are the same up to rotation and reflection
+share one entry, which makes the book up to eight times smaller. The minimax
+agent finds positions in either kind of book, but cmd/ttt only in books built
+without --symmetric.
+
+The book is written in binary, which the minimax agent loads with
+"minimax:book=file", or as a Go string constant to paste into cmd/ttt.`,
+		RunE: runCmd,
+	}
+
+	cmd.Flags().String(flagPlayer, "minimax:depth=3", "the agent which plays games if no records are given")
+	cmd.Flags().IntP(flagGames, "n", 200, "the number of games to play if no records are given")
+	cmd.Flags().Int(flagRandomPlies, 2, "the number of random plies in the openings of played games")
+	cmd.Flags().Uint64(flagSeed, 1, "the seed for openings")
+	cmd.Flags().String(flagAgent, "minimax:depth=6", "the agent which chooses the move in each position")
+	cmd.Flags().Duration(flagTime, 0, "the time the agent searches each position for, or to its depth if zero")
+	cmd.Flags().Int(flagPlies, 6, "the number of plies from the start of the game to include positions from")
+	cmd.Flags().Int(flagMinCount, 2, "the number of games which must reach a position to include it")
+	cmd.Flags().String(flagBook, "", "an existing book to deepen")
+	cmd.Flags().Bool(flagSymmetric, false, "store one entry for positions which are the same up to symmetry")
+	cmd.Flags().String(flagFormat, "binary", "the format to write, binary or go")
+	cmd.Flags().String(flagOut, "", "the file to write the book to, or stdout if empty")
+
+	return cmd
+}
+
+func runCmd(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	records, err := recordsFromFlags(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	plies, err := cmd.Flags().GetInt(flagPlies)
+	if err != nil {
+		return err
+	}
+	minCount, err := cmd.Flags().GetInt(flagMinCount)
+	if err != nil {
+		return err
+	}
+	symmetric, err := cmd.Flags().GetBool(flagSymmetric)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[uint64]ttt.Move)
+	path, err := cmd.Flags().GetString(flagBook)
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		book, err := ttt.LoadBook(path)
+		if err != nil {
+			return err
+		}
+		entries = book.Entries()
+	}
+	existing := ttt.BuildBook(entries)
+
+	var positions []*battle.BookPosition
+	for hash, position := range battle.BookPositions(records, plies, symmetric) {
+		if _, ok := existing.Lookup(hash); !ok && position.Count >= minCount {
+			positions = append(positions, position)
+		}
+	}
+	// Search the most common positions first.
+	slices.SortFunc(positions, func(a, b *battle.BookPosition) int {
+		return cmp.Or(b.Count-a.Count, cmp.Compare(ttt.FormatMoves(a.Moves), ttt.FormatMoves(b.Moves)))
+	})
+
+	spec, err := cmd.Flags().GetString(flagAgent)
+	if err != nil {
+		return err
+	}
+	agent, err := battle.ParseAgent(spec, 0)
+	if err != nil {
+		return err
+	}
+	limit, err := cmd.Flags().GetDuration(flagTime)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "searching %d positions from %d games\n", len(positions), len(records))
+	for i, position := range positions {
+		hash, move := battle.BookMove(agent, position.Moves, limit, symmetric)
+		entries[hash] = move
+		_, _ = fmt.Fprintf(os.Stderr, "%d/%d: %q (%d games): %s\n",
+			i+1, len(positions), ttt.FormatMoves(position.Moves), position.Count, move)
+	}
+
+	book := ttt.BuildBook(entries)
+	_, _ = fmt.Fprintf(os.Stderr, "%d positions in %d bytes\n", book.Len(), len(book.Data()))
+	return writeBook(cmd, book)
+}
+
+func recordsFromFlags(cmd *cobra.Command, args []string) ([]*battle.Record, error) {
+	if len(args) > 0 {
+		var records []*battle.Record
+		for _, path := range args {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			fileRecords, err := battle.ReadRecords(f)
+			_ = f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("reading records from %q: %w", path, err)
+			}
+			records = append(records, fileRecords...)
+		}
+		return records, nil
+	}
+
+	spec, err := cmd.Flags().GetString(flagPlayer)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := cmd.Flags().GetUint64(flagSeed)
+	if err != nil {
+		return nil, err
+	}
+	player, err := battle.ParseAgent(spec, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	games, err := cmd.Flags().GetInt(flagGames)
+	if err != nil {
+		return nil, err
+	}
+	if games < 1 {
+		return nil, errors.New("number of games must be positive")
+	}
+	randomPlies, err := cmd.Flags().GetInt(flagRandomPlies)
+	if err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewPCG(seed, 0))
+	openings, err := battle.RandomOpenings(rng, games, randomPlies)
+	if err != nil {
+		return nil, err
+	}
+	_, records := battle.Battle(player, player, openings, battle.TimeControl{})
+
+	// Battle plays each opening twice, which for an agent against itself is
+	// the same game unless the agent is random.
+	var unique []*battle.Record
+	for i := 0; i < len(records); i += 2 {
+		unique = append(unique, records[i])
+	}
+	return unique, nil
+}
+
+func writeBook(cmd *cobra.Command, book *ttt.Book) error {
+	format, err := cmd.Flags().GetString(flagFormat)
+	if err != nil {
+		return err
+	}
+
+	var data string
+	switch format {
+	case "binary":
+		data = book.Data()
+	case "go":
+		data = fmt.Sprintf("const openingBook = %s\n", strconv.Quote(book.Data()))
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	path, err := cmd.Flags().GetString(flagOut)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		_, err = os.Stdout.WriteString(data)
+		return err
+	}
+	return os.WriteFile(path, []byte(data), 0o644)
+}
@@ -0,0 +1,584 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func main() {
+	err := mainCmd().Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+const (
+	flagHuman  = "human"
+	flagEngine = "engine"
+	flagTime   = "time"
+	flagSeed   = "seed"
+	flagLoad   = "load"
+)
+
+const help = `Move the cursor with the arrow keys or hjkl and play with enter or space, or
+type a move as its row and column. u undoes your last move, s swaps sides, + and
+- make the engine stronger or weaker, : enters a command and q quits.`
+
+const commandHelp = `Commands are a move as "row col", undo, swap, level N, depth N, engine SPEC,
+time DURATION, save FILE, load FILE, help and quit.`
+
+func mainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `play`,
+		Short: `Plays ultimate tic-tac-toe against an engine in the terminal.`,
+		Long: `Plays ultimate tic-tac-toe against an engine in the terminal.
+
+The grid is drawn with X in red and O in blue, won boards shaded in the colour
+of their winner, and the cells you may play in marked in green.
+
+The engine is any agent battle accepts. Skill agents, "skill:level=1" to
+"skill:level=8", range from a little stronger than random to a deep search,
+with the approximate Elo of each level shown beside it.
+
+` + help + `
+
+` + commandHelp + `
+
+Saved games are move lists of "row col" pairs, as read by prove and analyze.
+Without a terminal, for example with input from a pipe, every line is read as
+a command.`,
+		RunE: runCmd,
+	}
+
+	cmd.Flags().String(flagHuman, "x", "the side you play, x or o, where x moves first")
+	cmd.Flags().String(flagEngine, "minimax:depth=4", "the agent you play against, for example skill:level=3")
+	cmd.Flags().Duration(flagTime, 0, "the time the engine has per move, or to its depth if zero")
+	cmd.Flags().Uint64(flagSeed, 1, "the seed for random agents")
+	cmd.Flags().String(flagLoad, "", "a saved game to continue")
+
+	return cmd
+}
+
+func runCmd(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+
+	s := &session{game: ttt.NewGame(), row: 4, col: 4}
+
+	human, err := cmd.Flags().GetString(flagHuman)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(human) {
+	case "x":
+		s.human = ttt.Self
+	case "o":
+		s.human = ttt.Opponent
+	default:
+		return fmt.Errorf("unknown side %q, want x or o", human)
+	}
+
+	s.seed, err = cmd.Flags().GetUint64(flagSeed)
+	if err != nil {
+		return err
+	}
+	spec, err := cmd.Flags().GetString(flagEngine)
+	if err != nil {
+		return err
+	}
+	s.engine, err = battle.ParseAgent(spec, s.seed)
+	if err != nil {
+		return err
+	}
+	s.limit, err = cmd.Flags().GetDuration(flagTime)
+	if err != nil {
+		return err
+	}
+
+	path, err := cmd.Flags().GetString(flagLoad)
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		err = s.load(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	t, ok := newTerminal()
+	if !ok {
+		return s.runLines(in, os.Stdout)
+	}
+
+	err = t.keys()
+	if err != nil {
+		return s.runLines(in, os.Stdout)
+	}
+	defer t.restore()
+
+	// Leave the terminal as it was if interrupted.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		_ = t.restore()
+		fmt.Println()
+		os.Exit(1)
+	}()
+
+	return s.runKeys(t, in, os.Stdout)
+}
+
+// session is a game between a human and an engine. The game is kept from the
+// perspective of X, the player who moved first.
+type session struct {
+	game  *ttt.Game
+	human ttt.Player
+
+	engine battle.Agent
+	limit  time.Duration
+	seed   uint64
+
+	// row and col are the cursor position on the 9x9 grid.
+	row, col uint8
+
+	// message is shown under the grid until the next key or command.
+	message string
+}
+
+// runKeys plays reading a key at a time.
+func (s *session) runKeys(t *terminal, in *bufio.Reader, out io.Writer) error {
+	// digit is the row of a move being typed, or -1.
+	digit := -1
+	for {
+		if s.result() == "" && !s.humanToMove() {
+			s.render(out, true, "thinking...")
+			s.engineMove()
+			continue
+		}
+		s.render(out, true, "")
+		s.message = ""
+
+		key, err := readKey(in)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case keyUp, "k":
+			s.row = max(s.row, 1) - 1
+		case keyDown, "j":
+			s.row = min(s.row+1, 8)
+		case keyLeft, "h":
+			s.col = max(s.col, 1) - 1
+		case keyRight, "l":
+			s.col = min(s.col+1, 8)
+		case keyEnter, " ":
+			s.play(ttt.FromRowCol(s.row, s.col))
+		case "u":
+			s.undo()
+		case "s":
+			s.swap()
+		case "+":
+			s.changeStrength(1)
+		case "-":
+			s.changeStrength(-1)
+		case ":":
+			err = t.restore()
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprint(out, ": ")
+			line, err := in.ReadString('\n')
+			if err != nil {
+				return err
+			}
+			if s.command(line) {
+				return nil
+			}
+			err = t.keys()
+			if err != nil {
+				return err
+			}
+		case "q":
+			return nil
+		default:
+			if len(key) == 1 && key[0] >= '0' && key[0] <= '8' {
+				if digit < 0 {
+					digit = int(key[0] - '0')
+					s.message = fmt.Sprintf("row %d, type the column", digit)
+					continue
+				}
+				s.row, s.col = uint8(digit), key[0]-'0'
+				s.play(ttt.FromRowCol(s.row, s.col))
+			}
+		}
+		digit = -1
+	}
+}
+
+// runLines plays reading a command per line.
+func (s *session) runLines(in *bufio.Reader, out io.Writer) error {
+	_, _ = fmt.Fprintln(out, commandHelp)
+	for {
+		if s.result() == "" && !s.humanToMove() {
+			s.engineMove()
+			continue
+		}
+		s.render(out, false, "")
+		s.message = ""
+
+		_, _ = fmt.Fprint(out, "> ")
+		line, err := in.ReadString('\n')
+		if errors.Is(err, io.EOF) && line == "" {
+			return nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if s.command(line) {
+			return nil
+		}
+	}
+}
+
+// command runs a typed command, and returns true if it is to quit.
+func (s *session) command(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+
+	arg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+	switch fields[0] {
+	case "undo":
+		s.undo()
+	case "swap":
+		s.swap()
+	case "depth":
+		depth, err := strconv.Atoi(arg)
+		if err != nil || depth < 1 {
+			s.message = fmt.Sprintf("invalid depth %q", arg)
+			break
+		}
+		if m, ok := s.engine.(*battle.Minimax); ok {
+			m.Depth = depth
+			s.message = "engine is " + s.engineName()
+		} else {
+			s.message = "only a minimax engine has a depth; choose one with engine"
+		}
+	case "level":
+		level, err := strconv.Atoi(arg)
+		if err != nil || level < 1 || level > len(ttt.SkillLevels) {
+			s.message = fmt.Sprintf("invalid level %q, want 1 to %d", arg, len(ttt.SkillLevels))
+			break
+		}
+		s.engine = battle.NewSkill(level, s.seed)
+		s.message = "engine is " + s.engineName()
+	case "engine":
+		engine, err := battle.ParseAgent(arg, s.seed)
+		if err != nil {
+			s.message = err.Error()
+			break
+		}
+		s.engine = engine
+		s.message = "engine is " + s.engineName()
+	case "time":
+		limit, err := time.ParseDuration(arg)
+		if err != nil {
+			s.message = err.Error()
+			break
+		}
+		s.limit = limit
+	case "save":
+		err := s.save(arg)
+		if err != nil {
+			s.message = err.Error()
+			break
+		}
+		s.message = "saved to " + arg
+	case "load":
+		err := s.load(arg)
+		if err != nil {
+			s.message = err.Error()
+			break
+		}
+		s.message = "loaded " + arg
+	case "help":
+		s.message = commandHelp
+	case "quit", "q":
+		return true
+	default:
+		moves, err := ttt.ParseMoves(line)
+		if err != nil || len(moves) != 1 {
+			s.message = fmt.Sprintf("unknown command %q", strings.TrimSpace(line))
+			break
+		}
+		s.row, s.col = moves[0].RowCol()
+		s.play(moves[0])
+	}
+	return false
+}
+
+// humanToMove returns true if it is the human's turn.
+func (s *session) humanToMove() bool {
+	return s.game.ToMove() == s.human
+}
+
+// result describes how the game ended, or is empty if it has not.
+func (s *session) result() string {
+	if winner := s.game.Winners.Winner(); winner != ttt.None {
+		return fmt.Sprintf("%c wins", ttt.Symbol(winner))
+	}
+	moves := make([]ttt.Move, 81)
+	if s.game.Moves(moves) == 0 {
+		return "draw"
+	}
+	if s.game.Drawn() {
+		return "draw, neither player can win"
+	}
+	return ""
+}
+
+// play makes move for the human, if it is legal.
+func (s *session) play(move ttt.Move) {
+	if result := s.result(); result != "" {
+		s.message = "the game is over: " + result
+		return
+	}
+	err := s.game.Validate(move)
+	if err != nil {
+		s.message = err.Error()
+		return
+	}
+	s.game.Play(move)
+}
+
+// engineMove makes the engine's move. The engine plays as ttt.Self, so it is
+// given the game from its own perspective.
+func (s *session) engineMove() {
+	game, legal, err := ttt.Position(s.game.History())
+	if err != nil {
+		s.message = err.Error()
+		s.human = s.game.ToMove()
+		return
+	}
+
+	start := time.Now()
+	move := s.engine.PickMove(game, legal, s.limit)
+	if err := s.game.Validate(move); err != nil {
+		s.message = fmt.Sprintf("engine chose an %v; your move", err)
+		s.human = s.game.ToMove()
+		return
+	}
+	s.game.Play(move)
+	s.row, s.col = move.RowCol()
+	s.message = fmt.Sprintf("%s played %v in %v", s.engine.Name(), move, time.Since(start).Round(time.Millisecond))
+}
+
+// undo takes back moves until the human's last move is taken back.
+func (s *session) undo() {
+	undone := false
+	for {
+		mover := -s.game.ToMove()
+		if !s.game.Undo() {
+			break
+		}
+		undone = undone || mover == s.human
+		if undone && s.humanToMove() {
+			break
+		}
+	}
+	if !undone {
+		s.message = "nothing to undo"
+	}
+}
+
+// swap gives the human the engine's side.
+func (s *session) swap() {
+	s.human = -s.human
+	s.message = fmt.Sprintf("you play %c", ttt.Symbol(s.human))
+}
+
+// changeStrength changes the depth of a minimax engine, or the level of a
+// skill engine, by delta.
+func (s *session) changeStrength(delta int) {
+	switch engine := s.engine.(type) {
+	case *battle.Minimax:
+		engine.Depth = max(engine.Depth+delta, 1)
+	case *battle.Skill:
+		engine.Level = min(max(engine.Level+delta, 1), len(ttt.SkillLevels))
+	default:
+		s.message = "only minimax and skill engines can be changed; choose one with :engine"
+		return
+	}
+	s.message = "engine is " + s.engineName()
+}
+
+// engineName names the engine, with its approximate Elo for skill levels.
+func (s *session) engineName() string {
+	if k, ok := s.engine.(*battle.Skill); ok {
+		return fmt.Sprintf("%s (Elo %+d)", k.Name(), ttt.SkillLevels[k.Level-1].Elo)
+	}
+	return s.engine.Name()
+}
+
+// save writes the moves of the game to path.
+func (s *session) save(path string) error {
+	if path == "" {
+		return errors.New("save needs a file")
+	}
+	return os.WriteFile(path, []byte(ttt.FormatMoves(s.game.History())+"\n"), 0o644)
+}
+
+// load replaces the game with the one saved at path.
+func (s *session) load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	moves, err := ttt.ParseMoves(string(data))
+	if err != nil {
+		return fmt.Errorf("reading %q: %w", path, err)
+	}
+
+	game := ttt.NewGame()
+	for i, move := range moves {
+		err = game.Validate(move)
+		if err != nil {
+			return fmt.Errorf("reading %q: after %q: %w", path, ttt.FormatMoves(moves[:i]), err)
+		}
+		game.Play(move)
+	}
+	s.game = game
+	return nil
+}
+
+// ANSI escape sequences for drawing.
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	reset       = "\x1b[0m"
+	bold        = "\x1b[1m"
+	reverse     = "\x1b[7m"
+	red         = "\x1b[31m"
+	blue        = "\x1b[34m"
+	green       = "\x1b[32m"
+	redShade    = "\x1b[41m"
+	blueShade   = "\x1b[44m"
+)
+
+var (
+	rowNames = [3]string{"top", "middle", "bottom"}
+	colNames = [3]string{"left", "centre", "right"}
+)
+
+// render draws the game. If cursor, the screen is cleared first and the
+// cursor drawn. status, if set, replaces the line saying whose turn it is.
+func (s *session) render(w io.Writer, cursor bool, status string) {
+	sb := strings.Builder{}
+	if cursor {
+		sb.WriteString(clearScreen)
+	}
+
+	legal := make(map[ttt.Move]bool)
+	if s.result() == "" && s.humanToMove() {
+		moves := make([]ttt.Move, 81)
+		for _, move := range moves[:s.game.Moves(moves)] {
+			legal[move] = true
+		}
+	}
+
+	sb.WriteString("    0 1 2   3 4 5   6 7 8\n")
+	for row := uint8(0); row < 9; row++ {
+		if row > 0 && row%3 == 0 {
+			sb.WriteString("   -------+-------+-------\n")
+		}
+		_, _ = fmt.Fprintf(&sb, " %d ", row)
+		for col := uint8(0); col < 9; col++ {
+			if col > 0 && col%3 == 0 {
+				sb.WriteString(" |")
+			}
+			sb.WriteByte(' ')
+
+			move := ttt.FromRowCol(row, col)
+			a, b, x, y := move.XBoard(), move.YBoard(), move.XCell(), move.YCell()
+			cell := s.game.Boards[a][b].Cells[x][y]
+
+			style := ""
+			switch s.game.Winners.Cells[a][b] {
+			case ttt.Self:
+				style += redShade
+			case ttt.Opponent:
+				style += blueShade
+			}
+			switch {
+			case cell == ttt.Self:
+				style += bold + red
+			case cell == ttt.Opponent:
+				style += bold + blue
+			case legal[move]:
+				style += bold + green
+			}
+			if cursor && row == s.row && col == s.col {
+				style += reverse
+			}
+
+			symbol := ttt.Symbol(cell)
+			if cell == ttt.None && legal[move] {
+				symbol = '+'
+			}
+			if style == "" {
+				sb.WriteByte(symbol)
+			} else {
+				sb.WriteString(style)
+				sb.WriteByte(symbol)
+				sb.WriteString(reset)
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('\n')
+
+	you := map[bool]string{true: " (you)", false: ""}
+	_, _ = fmt.Fprintf(&sb, "X%s, O%s: %s", you[s.human == ttt.Self], you[s.human == ttt.Opponent], s.engineName())
+	if s.limit > 0 {
+		_, _ = fmt.Fprintf(&sb, " with %v per move", s.limit)
+	}
+	sb.WriteByte('\n')
+
+	switch result := s.result(); {
+	case status != "":
+		sb.WriteString(status)
+	case result != "":
+		sb.WriteString(result)
+	default:
+		_, _ = fmt.Fprintf(&sb, "%c to move", ttt.Symbol(s.game.ToMove()))
+		if forced := s.game.Forced(); forced != ttt.Anywhere {
+			_, _ = fmt.Fprintf(&sb, " in the %s %s board", rowNames[forced/3], colNames[forced%3])
+		} else {
+			sb.WriteString(" anywhere")
+		}
+	}
+	sb.WriteByte('\n')
+
+	if s.message != "" {
+		sb.WriteString(s.message)
+		sb.WriteByte('\n')
+	}
+	if cursor {
+		sb.WriteString("\n" + help + "\n")
+	}
+
+	_, _ = io.WriteString(w, sb.String())
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Keys read from the terminal which are not single characters.
+const (
+	keyUp    = "up"
+	keyDown  = "down"
+	keyLeft  = "left"
+	keyRight = "right"
+	keyEnter = "enter"
+)
+
+// terminal switches stdin between reading single keys and reading lines, with
+// stty, which exists on Linux and macOS.
+type terminal struct {
+	// saved is the state of the terminal before it was switched, as printed by
+	// "stty -g".
+	saved string
+}
+
+// newTerminal returns a terminal if stdin is one whose mode can be changed.
+func newTerminal() (*terminal, bool) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, false
+	}
+	return &terminal{saved: strings.TrimSpace(saved)}, true
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// keys makes input available a key at a time, without echo. Ctrl-C still
+// interrupts.
+func (t *terminal) keys() error {
+	_, err := stty("-icanon", "-echo", "min", "1")
+	return err
+}
+
+// restore returns the terminal to the state it was in when found.
+func (t *terminal) restore() error {
+	_, err := stty(t.saved)
+	return err
+}
+
+// readKey reads one key, translating arrow keys and enter to the names above.
+func readKey(r *bufio.Reader) (string, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	switch c {
+	case '\r', '\n':
+		return keyEnter, nil
+	case 0x1b:
+		// Arrow keys are sent as ESC [ A to ESC [ D.
+		if r.Buffered() < 2 {
+			return "", nil
+		}
+		if next, _ := r.ReadByte(); next != '[' {
+			return "", nil
+		}
+		arrow, _ := r.ReadByte()
+		switch arrow {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			return keyRight, nil
+		case 'D':
+			return keyLeft, nil
+		}
+		return "", nil
+	default:
+		return string(c), nil
+	}
+}
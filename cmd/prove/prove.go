@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"time"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func main() {
+	err := mainCmd().Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+const (
+	flagNodes = "nodes"
+	flagTime  = "time"
+)
+
+func mainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `prove [moves...]`,
+		Short: `Searches for a forced win for the player to move.`,
+		Long: `Searches for a forced win for the player to move.
+
+The position is given by the moves played from the start of the game, as
+"row col" pairs, for example "prove 4 4 3 3". Proof-number search either proves
+that the player to move can force a win, proves that the opponent can always
+draw or win, or runs out of nodes or time first.
+
+A proved win is printed with a winning line, where the opponent makes the
+replies which take the most effort to refute. The player to move is drawn as X.`,
+		RunE: runCmd,
+	}
+
+	cmd.Flags().Int(flagNodes, ttt.DefaultProofNodes, "the most positions to search")
+	cmd.Flags().Duration(flagTime, 0, "the time to search for, or without a limit if zero")
+
+	return cmd
+}
+
+func runCmd(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	moves, err := ttt.ParseMoves(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	game, legal, err := ttt.Position(moves)
+	if err != nil {
+		return err
+	}
+
+	p := ttt.NewProver()
+	p.MaxNodes, err = cmd.Flags().GetInt(flagNodes)
+	if err != nil {
+		return err
+	}
+	limit, err := cmd.Flags().GetDuration(flagTime)
+	if err != nil {
+		return err
+	}
+	if limit != 0 {
+		p.Deadline = time.Now().Add(limit)
+	}
+
+	fmt.Println(game)
+
+	start := time.Now()
+	proof, line := p.Prove(legal, game)
+	fmt.Printf("%s in %d nodes, %v\n", proof, p.Nodes(), time.Since(start).Round(time.Millisecond))
+	if proof == ttt.Proved {
+		fmt.Printf("line: %s\n", ttt.FormatMoves(line))
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"runtime"
+	"ultimate-tic-tac-toe/pkg/battle"
+)
+
+func main() {
+	err := mainCmd().Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+const (
+	flagAgent       = "agent"
+	flagGames       = "games"
+	flagWorkers     = "workers"
+	flagEpsilon     = "epsilon"
+	flagTemperature = "temperature"
+	flagRandomPlies = "random-plies"
+	flagSeed        = "seed"
+	flagFormat      = "format"
+	flagOut         = "out"
+)
+
+func mainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `selfplay`,
+		Short: `Generates positions by playing the minimax search against itself.`,
+		Long: `Generates positions by playing the minimax search against itself.
+
+Every position where a move is chosen is written with the player to move, the
+board they are forced to play in, the search score and best move, and the
+eventual result of the game for the player to move. The agent's depth, weights
+and network are used, and it may not have a book.
+
+The binary format stores each position in 29 bytes. The JSON lines format
+writes one position per line.`,
+		RunE: runCmd,
+	}
+
+	cmd.Flags().String(flagAgent, "minimax:depth=3", "the minimax agent to play")
+	cmd.Flags().IntP(flagGames, "n", 100, "the number of games to play")
+	cmd.Flags().Int(flagWorkers, runtime.NumCPU(), "the number of games to play in parallel")
+	cmd.Flags().Float64(flagEpsilon, 0.0, "the probability of playing a random move")
+	cmd.Flags().Float64(flagTemperature, 0.0, "if positive, sample moves by exp(score/temperature)")
+	cmd.Flags().Int(flagRandomPlies, 0, "the number of plies randomness applies to, or zero for all")
+	cmd.Flags().Uint64(flagSeed, 1, "the seed of the first game")
+	cmd.Flags().String(flagFormat, "binary", "the format to write, binary or json")
+	cmd.Flags().String(flagOut, "", "the file to write to, or stdout if empty")
+
+	return cmd
+}
+
+func runCmd(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+
+	spec, err := cmd.Flags().GetString(flagAgent)
+	if err != nil {
+		return err
+	}
+	agent, err := battle.ParseAgent(spec, 0)
+	if err != nil {
+		return err
+	}
+	minimax, ok := agent.(*battle.Minimax)
+	if !ok {
+		return fmt.Errorf("agent %q is not a minimax agent", spec)
+	}
+
+	// Every move is scored by searching, so there is no way to play from a
+	// book.
+	if minimax.Book != nil {
+		return fmt.Errorf("agent %q: self-play cannot use a book", spec)
+	}
+
+	sp := &battle.SelfPlay{Depth: minimax.Depth, Weights: minimax.Weights, Network: minimax.Network}
+	sp.Epsilon, err = cmd.Flags().GetFloat64(flagEpsilon)
+	if err != nil {
+		return err
+	}
+	sp.Temperature, err = cmd.Flags().GetFloat64(flagTemperature)
+	if err != nil {
+		return err
+	}
+	sp.RandomPlies, err = cmd.Flags().GetInt(flagRandomPlies)
+	if err != nil {
+		return err
+	}
+
+	games, err := cmd.Flags().GetInt(flagGames)
+	if err != nil {
+		return err
+	}
+	workers, err := cmd.Flags().GetInt(flagWorkers)
+	if err != nil {
+		return err
+	}
+	if workers < 1 {
+		return fmt.Errorf("workers must be positive")
+	}
+	seed, err := cmd.Flags().GetUint64(flagSeed)
+	if err != nil {
+		return err
+	}
+
+	format, err := cmd.Flags().GetString(flagFormat)
+	if err != nil {
+		return err
+	}
+
+	path, err := cmd.Flags().GetString(flagOut)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return writeSamples(os.Stdout, format, sp, games, workers, seed)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = writeSamples(f, format, sp, games, workers, seed)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeSamples plays games of sp with workers in parallel, starting from seed,
+// and writes their positions to out in format.
+func writeSamples(out io.Writer, format string, sp *battle.SelfPlay, games, workers int, seed uint64) error {
+	var write func([]battle.Sample) error
+	var flush func() error
+	switch format {
+	case "binary":
+		sw := battle.NewSampleWriter(out)
+		write = func(samples []battle.Sample) error {
+			for i := range samples {
+				err := sw.Write(&samples[i])
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		flush = sw.Flush
+	case "json":
+		bw := bufio.NewWriter(out)
+		encoder := json.NewEncoder(bw)
+		write = func(samples []battle.Sample) error {
+			for i := range samples {
+				err := encoder.Encode(&samples[i])
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		flush = bw.Flush
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	positions := 0
+	err := sp.Run(games, workers, seed, func(samples []battle.Sample) error {
+		positions += len(samples)
+		return write(samples)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%d positions from %d games\n", positions, games)
+	return flush()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"math/rand/v2"
+	"os"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func main() {
+	err := mainCmd().Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+const (
+	flagHidden       = "hidden"
+	flagEpochs       = "epochs"
+	flagBatch        = "batch"
+	flagLearningRate = "learning-rate"
+	flagLambda       = "lambda"
+	flagScale        = "scale"
+	flagValidation   = "validation"
+	flagSeed         = "seed"
+	flagUnique       = "unique"
+	flagStart        = "start"
+	flagOut          = "out"
+)
+
+func mainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `train samples...`,
+		Short: `Trains a neural-network evaluator on self-play positions.`,
+		Long: `Trains a neural-network evaluator on self-play positions.
+
+Reads positions written by selfplay in either format, and trains a small fully
+connected network to predict, for the player to move, a mix of the result of
+the game and the search score squashed by tanh(score/scale).
+
+The network is written as JSON, which the minimax agent loads with
+"minimax:network=file".`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runCmd,
+	}
+
+	cmd.Flags().IntSlice(flagHidden, []int{64, 32}, "the widths of the one or two hidden layers")
+	cmd.Flags().Int(flagEpochs, 20, "the number of passes over the training positions")
+	cmd.Flags().Int(flagBatch, 256, "the number of positions per update")
+	cmd.Flags().Float64(flagLearningRate, 1e-3, "the Adam learning rate")
+	cmd.Flags().Float64(flagLambda, 0.5, "the weight of the game result in the target, against the search score")
+	cmd.Flags().Float64(flagScale, 100, "the evaluation units of a prediction of one")
+	cmd.Flags().Float64(flagValidation, 0.1, "the fraction of positions held out to measure loss")
+	cmd.Flags().Uint64(flagSeed, 1, "the seed for initialization and shuffling")
+	cmd.Flags().Bool(flagUnique, false, "train on only the first position of those which are the same up to symmetry")
+	cmd.Flags().String(flagStart, "", "a network file to continue training instead of a new network")
+	cmd.Flags().String(flagOut, "", "the file to write the network to, or stdout if empty")
+
+	return cmd
+}
+
+func runCmd(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	var samples []battle.Sample
+	for _, path := range args {
+		fileSamples, err := battle.LoadSamples(path)
+		if err != nil {
+			return err
+		}
+		samples = append(samples, fileSamples...)
+	}
+	if len(samples) == 0 {
+		return errors.New("no positions to train on")
+	}
+	unique, err := cmd.Flags().GetBool(flagUnique)
+	if err != nil {
+		return err
+	}
+	if unique {
+		n := len(samples)
+		samples = battle.UniqueSamples(samples)
+		_, _ = fmt.Fprintf(os.Stderr, "kept %d of %d positions\n", len(samples), n)
+	}
+
+	lambda, err := cmd.Flags().GetFloat64(flagLambda)
+	if err != nil {
+		return err
+	}
+	if lambda < 0 || lambda > 1 {
+		return errors.New("lambda must be between 0 and 1")
+	}
+	scale, err := cmd.Flags().GetFloat64(flagScale)
+	if err != nil {
+		return err
+	}
+	if scale <= 0 {
+		return errors.New("scale must be positive")
+	}
+	seed, err := cmd.Flags().GetUint64(flagSeed)
+	if err != nil {
+		return err
+	}
+	rng := rand.New(rand.NewPCG(seed, 0))
+
+	examples := make([]ttt.Example, len(samples))
+	for i := range samples {
+		examples[i] = samples[i].Example(lambda, scale)
+	}
+	rng.Shuffle(len(examples), func(i, j int) {
+		examples[i], examples[j] = examples[j], examples[i]
+	})
+
+	validation, err := cmd.Flags().GetFloat64(flagValidation)
+	if err != nil {
+		return err
+	}
+	nValidation := int(validation * float64(len(examples)))
+	if nValidation >= len(examples) {
+		return errors.New("validation fraction leaves no positions to train on")
+	}
+	held, training := examples[:nValidation], examples[nValidation:]
+
+	network, err := networkFromFlags(cmd, scale, rng)
+	if err != nil {
+		return err
+	}
+
+	trainer := ttt.NewTrainer(network)
+	trainer.LearningRate, err = cmd.Flags().GetFloat64(flagLearningRate)
+	if err != nil {
+		return err
+	}
+	trainer.BatchSize, err = cmd.Flags().GetInt(flagBatch)
+	if err != nil {
+		return err
+	}
+	if trainer.BatchSize < 1 {
+		return errors.New("batch size must be positive")
+	}
+
+	epochs, err := cmd.Flags().GetInt(flagEpochs)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%d training and %d validation positions\n", len(training), len(held))
+	for epoch := 1; epoch <= epochs; epoch++ {
+		loss := trainer.Epoch(training, rng)
+		if len(held) > 0 {
+			_, _ = fmt.Fprintf(os.Stderr, "epoch %d: loss %.6f, validation loss %.6f\n", epoch, loss, trainer.Loss(held))
+		} else {
+			_, _ = fmt.Fprintf(os.Stderr, "epoch %d: loss %.6f\n", epoch, loss)
+		}
+	}
+
+	out, err := cmd.Flags().GetString(flagOut)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		return ttt.WriteNetwork(os.Stdout, network)
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	err = ttt.WriteNetwork(f, network)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func networkFromFlags(cmd *cobra.Command, scale float64, rng *rand.Rand) (*ttt.Network, error) {
+	start, err := cmd.Flags().GetString(flagStart)
+	if err != nil {
+		return nil, err
+	}
+	if start != "" {
+		return ttt.LoadNetwork(start)
+	}
+
+	hidden, err := cmd.Flags().GetIntSlice(flagHidden)
+	if err != nil {
+		return nil, err
+	}
+	if len(hidden) < 1 || len(hidden) > 2 {
+		return nil, errors.New("there must be one or two hidden layers")
+	}
+	for _, width := range hidden {
+		if width < 1 || width > ttt.MaxHidden {
+			return nil, fmt.Errorf("hidden layer widths must be between 1 and %d", ttt.MaxHidden)
+		}
+	}
+
+	return ttt.NewNetwork(hidden, scale, rng), nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func main() {
+	err := mainCmd().Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+const (
+	flagDepth  = "depth"
+	flagRecord = "record"
+	flagFormat = "format"
+	flagOut    = "out"
+)
+
+func mainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `ttt-tree [moves...]`,
+		Short: `Writes the tree the minimax search explores from a position.`,
+		Long: `Writes the tree the minimax search explores from a position.
+
+The position is given by the moves played from the start of the game, as
+"row col" pairs, for example "ttt-tree 4 4 3 3". The player to move is drawn as
+X. The search picks a move as the minimax agent does, recording the positions
+it visits to the record depth with their scores for X, the alpha-beta window
+of each position, cutoffs and transposition table hits.
+
+Positions with few empty cells are solved exactly, in which case the tree shows
+outcomes of -1, 0 and 1.
+
+The tree is written as a Graphviz digraph, with the principal variation in red,
+or as JSON. Render the digraph with, for example, "dot -Tsvg".`,
+		RunE: runCmd,
+	}
+
+	cmd.Flags().Int(flagDepth, 3, "the depth to search to")
+	cmd.Flags().Int(flagRecord, 2, "the depth to record the tree to")
+	cmd.Flags().String(flagFormat, "dot", "the format to write, dot or json")
+	cmd.Flags().String(flagOut, "", "the file to write the tree to, or stdout if empty")
+
+	return cmd
+}
+
+func runCmd(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	moves, err := ttt.ParseMoves(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	game, legal, err := ttt.Position(moves)
+	if err != nil {
+		return err
+	}
+
+	depth, err := cmd.Flags().GetInt(flagDepth)
+	if err != nil {
+		return err
+	}
+	if depth < 1 {
+		return errors.New("depth must be positive")
+	}
+	record, err := cmd.Flags().GetInt(flagRecord)
+	if err != nil {
+		return err
+	}
+
+	s := ttt.NewSearcher()
+	s.Tree = ttt.NewSearchTree(record)
+	choice := s.PickMove(legal, game, depth)
+	_, _ = fmt.Fprintf(os.Stderr, "%s\nchose %s after %d nodes\n", game, choice, s.Nodes())
+
+	return writeTree(cmd, s.Tree)
+}
+
+func writeTree(cmd *cobra.Command, tree *ttt.SearchTree) error {
+	format, err := cmd.Flags().GetString(flagFormat)
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	switch format {
+	case "dot":
+		err = tree.WriteDOT(buf)
+	case "json":
+		encoder := json.NewEncoder(buf)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(tree.Root)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	path, err := cmd.Flags().GetString(flagOut)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
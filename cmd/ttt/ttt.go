@@ -81,7 +81,10 @@ func main() {
 			fmt.Fprintf(os.Stderr, "%v\n", game.Winners)
 		}
 
-		choice := PickMove(moves, game, maxDepth)
+		choice, ok := BookMove(moves, game)
+		if !ok {
+			choice = PickMove(moves, game, maxDepth)
+		}
 
 		game.WithMove(choice, Self)
 		choiceX := choice[0].X*3 + choice[1].X
@@ -177,10 +180,14 @@ func (b *Board) LegalMoves(out []Move) int {
 type Game struct {
 	Boards  [3][3]*Board
 	Winners *Board
+
+	// hash is the Zobrist hash of the cells, as in pkg/ttt.
+	hash uint64
 }
 
 func (g *Game) WithMove(move [2]Move, player Player) (bool, bool) {
 	boardWinner := g.Boards[move[0].X][move[0].Y].WithMove(move[1], player)
+	g.hash ^= zobristCell(move, player)
 	var gameWinner bool
 	if boardWinner {
 		gameWinner = g.Winners.WithMove(move[0], player)
@@ -191,6 +198,7 @@ func (g *Game) WithMove(move [2]Move, player Player) (bool, bool) {
 
 func (g *Game) WithoutMove(move [2]Move, player Player, wasBoardWin bool) {
 	g.Boards[move[0].X][move[0].Y].WithoutMove(move[1], player)
+	g.hash ^= zobristCell(move, player)
 
 	if wasBoardWin {
 		g.Winners.WithoutMove(move[0], player)
@@ -344,3 +352,81 @@ func PickMove(moves [][2]Move, game *Game, depth int) [2]Move {
 	}
 	return choice
 }
+
+// The opening book and hashing match ttt.Book and ttt.Game.Hash, so books
+// written by cmd/book with --format go can be pasted below.
+
+var (
+	zobristCells  [81][2]uint64
+	zobristForced [10]uint64
+)
+
+func init() {
+	state := uint64(0)
+	for i := range zobristCells {
+		zobristCells[i][0] = splitmix64(&state)
+		zobristCells[i][1] = splitmix64(&state)
+	}
+	for i := range zobristForced {
+		zobristForced[i] = splitmix64(&state)
+	}
+}
+
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func zobristCell(move [2]Move, player Player) uint64 {
+	keys := &zobristCells[(move[0].Y*3+move[1].Y)*9+move[0].X*3+move[1].X]
+	if player == Self {
+		return keys[0]
+	}
+	return keys[1]
+}
+
+// BookMove returns the move from the opening book for game, where Self may
+// play moves, if the position is in the book.
+func BookMove(moves [][2]Move, game *Game) ([2]Move, bool) {
+	forced := int(moves[0][0].Y*3 + moves[0][0].X)
+	for _, move := range moves {
+		if move[0] != moves[0][0] {
+			forced = -1
+			break
+		}
+	}
+	key := (game.hash ^ zobristForced[forced+1]) >> 24
+
+	// Entries are 5 bytes of key and a move, sorted by key.
+	lo, hi := 0, len(openingBook)/6
+	for lo < hi {
+		mid := (lo + hi) / 2
+		entry := openingBook[mid*6:]
+		entryKey := uint64(entry[0])<<32 | uint64(entry[1])<<24 | uint64(entry[2])<<16 | uint64(entry[3])<<8 | uint64(entry[4])
+		switch {
+		case entryKey < key:
+			lo = mid + 1
+		case entryKey > key:
+			hi = mid
+		default:
+			m := entry[5]
+			choice := [2]Move{
+				{X: int8(m >> 6), Y: int8(m >> 4 & 3)},
+				{X: int8(m >> 2 & 3), Y: int8(m & 3)},
+			}
+			for _, move := range moves {
+				if move == choice {
+					return choice, true
+				}
+			}
+			return choice, false
+		}
+	}
+
+	return [2]Move{}, false
+}
+
+const openingBook = "\x00\x132\x92\x10\xa0\x00\x8f\xbcGm\x02\x00\x96\xcbo\xcf\x05\x00\xed\xaa\x8a\x82\x02\x04.\x80\x7f\xa3\x05\x04\xfa\xc0\x9b``\x05U\x01h\xef\x02\x05\xda\xe8,\xa8\x02\b\x1aA\x82\x1a%\b\xc5t\xf2s \n\x12F\xa6\xf9P\nF&nr\x02\n\xa8\xf3\xe4n(\v\xa5;K\x8b\x02\rt`\xef^(\r\x87\xdf.\xbd(\x0e\x8b\x89\x94D(\x0e\xb2n\xfb*U\x104\x06-T\x90\x10\x91\x17\x9b\xcb \x11{1\xd4A\x02\x13\x17\xe2\xdb\xd0\x05\x13\x93\xc9d3\x02\x14{\xfeܿ\x02\x14\x85\xf7\x9e}@\x14\xd8\xd6o\x11\x80\x15\xb0\x95ͳ\x02\x16/4x\xe1(\x16\xfe\xdc?\xe6\x05\x17\x02*Ӊ(\x17\xd8lqR*\x19\n\x97?C\x05\x1a(>\xad\xa7\x05\x1a\x98L}\xe9\x02\x1d\x1d\x94}\xd9\x05\x1e';\xa6\x81\x02\x1e\xab\x05]@\x05\x1e\xc1+\xbf\x88%\x1e߰\t\x9bP\x1f\xe0\xe7\x1d8\x02 uA\xf9V\x02!L&\t\xfc\x05!P\x92\xe9\xb1(!\x83\xe9\xe1N\x05\"\v\x918^\x85\"\x7f\xf7E9\x05\"\x80l\x10O(#\x17\xa0\xc8p\x80$\xf2{\b\x8e\x05%\x15\xeb/E\x05%\xbb\x8b\x8e,\x02&0\xe4\xd3D\x05&f\x01a\xc8\x02(.Bc+\x10)\x83\xf4\xb7;\x80*/\xe7\xf9\xd9@*\xb2\xbdz\xee\x05*\xcd\xec\xfd\xc3\x02,\x0fԑ\x1d\x10,\xb2\x82\x89g(-Җ+\x06\x05.ZvT4\x02/-\x89\xd4\xcc\x02/Ve\x15\xb4\x90/g\x1b\xf5\xd5*/\xa1\xed\xdaO(2W'\x04\xb1(2\xd5Yp\xfb\x023\x1dT\n\fR3\xa3\xa1[T\xa04T\x86\x89\xee\x884\x96N.XU7a_V9\x028U\xb6\xc7\xdf(8\xc0\xab\x94|`:Ɠ\xbch\x80;?\xef\a\xf5\x10<j\t\xbb{\x05=^\x98#\xcd\x02>iF;\xf4\x02?\x95\x95\x99\x04 ?\xcb\xf2b\xdf\x02A\x82\xa9\xfc\x1d\x90A\xe18 vUBl\xe6\x9e3bB\xa4\xe3=;\x80B\xe2p>\xaa\x02B\xef\xbbN\x8c*C\xa2\x9eT\xb0@Dv\x97HS\x02E\xdeh^\xc5\x02F\x8av9\xfb\x05F\xac?P \x02G\x02\xf8\xe3X\x05G\xd9\x18)\xb0\x90H\x9f\xaa\xeb\x16(I\xd0\xfceѥJP7D\xa7\x02JW}+\xda\x02Jx\xddI( J\xb5\xec\x9d\xcf\x02K\f{r\x8e\x02Kb\x83\x01\xb1\x90L]\xa4;#\x05N\xf8\x18\x92\x90UP\xc1\xe0Dk\x05R%\x8f\xe3)(S@.\xa2A(U\x81\xc4ރ\x05X\x04J\xe2| X\xc3\x0fy\xcb*Y*X\nX(YFu\xc4d\x05Y\x8d\xb9b\x06\x05Y\xabD\xb7/UY\xddќ0\x10Z\xb5\x1e\xbf\xe3\x02[\xb0\x86{\x93`^\x1a(\xf1Ԁ_ҐY\x17%`\x16\x83\xe7\fDa:\xf0\xca$\x02a\x9d\xfaE\x1f b;\xd8\x01o(b\xa1\xf3v\xe6\x05cqs\x8d/\x02f\x8b\xdd\xcc\f(f\xb0T\n\t(gl\x01Vb\x02i\xe1D\xcb\xd7%j\xd3U\xba\xad(k\r\x84\xac\t\x90l\xe7\xdf\xefo\x05mN\xbaot\x05m\x83\x1e)\xe8\x05m\xa5\xc6l\xc3 m\xd4^\xd0a\x05nw\x11\xe4\xa7\x05o\xae\xb3\xf4\x86%p{\xe0\xddt\x12q\x1c\xfd\xac4Ur\x81\x84u\xef\x05r\x94\xc9\x15\x0e\x05s\xb4@*b\x02s\xba\x91x\x80\x02s\xc9X\x94p\x05tP\x94\r\x8e\x02u\x92\xe9A\xdd\x05v\x00\v\x7f\xc0\x02wg\x9e\xb5\tPw\x95\xbctn\x05w\xa7\x1e\x03\x90\"x^7,\xba\by)ri\xdc\x05yM\x92\xffP\x05z2u8\xef(z9|\x90\xd1`z\xc5'\x9d)({\x13\xfc\x90\xee%{\x1av\xb3@P|)\xd5w[\x02|\xca\xf3Z\x05\b|\xd9#\xbf\x92\x02~0\xdcٮ(~\x9bHD;\x05\x81JT\xed\x9c`\x81\x9bpr\x82\x02\x81\xa7X\x81X\x05\x82\x8f\xac\xf6\x1b\x05\x83)\xc2\x14\xf6\b\x84\x06\xee\xde%\x02\x84\xf5Q\x1f\xc6\b\x85\xa0^\xce=\x05\x87\n\xb8d\xdc\x02\x87q\xac\xaa\xf9\x05\x88\x1dI\xbb0\xa0\x89\x0e\x8d\xb7\xf5%\x89l\x9bz\x1a(\x8ba\xe0\xb2\xeb\x11\x8b\xf8\x0fgt\x05\x8c[\xd9\xdc0(\x8c\xd40\x98w(\x8d5\xb8\x96]\x05\x91\xca\xcb\xfdH\x05\x92WRֿ\x90\x92\x90eX\xd5\x05\x93\x19}\x8dq%\x97*4\xad\xd8(\x97@\x1aO\x10\x02\x97\xa6\nV\x19%\x98b\xd1{\xe7P\x98\x84\xbd~\x82\xa0\x98\xcd\xe9\x8bQU\x99=\x8e\x82Р\x9a\x12\xf8\x94\xab(\x9aB\xaer-\x05\x9b^\x9f\x82\x03\x05\x9bpV\xd4\xff@\x9c\x15-'\xc4`\x9c1\xa4=+%\x9c\xf6\xa3\xbe,\x05\x9d\x9bE\xee\x7f\x05\x9d\xfa\xcf,'(\x9eY]\x81\xca\x02\x9eyۙ7\x80\x9e\x83\x1b#\x11\b\x9f\x1cX(\x86\x10\x9f\xae\x05\x88y\x02\x9fӗ\xc04\x10\xa3C\f4=U\xa3L\xdd\r[(\xa53\xb3y\xff\b\xa6 \xdc*\xd7\b\xa6\xe6*\x05M\x02\xa7\x8c\x94\x00K@\xa7\xa9\xd8WK\x80\xa8ѣ\x19)\x02\xa9\xf4p\t\xce(\xab\x01]\xe0\xd7\x02\xab\xf36\x14\xe6\x05\xac:\xba~\xb4(\xacf\xfe\x9e\x19P\xac\x85P\t\xc5\x05\xac\x91\xe9%b\x05\xac\x94\xda\xdf\xdd(\xac\xbfd$\x0fB\xadsJ\xf8\x16 \xaf}\xc4\xf7\xab\x01\xaf\xe70\x91P(\xb1\x1ep\xbe\xe2P\xb2\x0f\x19$\x01\x82\xb3\x9f\xa8\xf1\xf9\x05\xb4ߩ\xd3U(\xb6JÒG*\xb6an\xbb\xc5@\xb6\x9d\xe4Y\xb0@\xb7\xe8w\xcbl(\xba\xb11\xb6k\x10\xbb\xd6\x16\xf4)\x02\xbd\x90\xd8\xe9$\xa0\xbe\xe0n\xa6\xa1(\xc0\xd6]\xfbW\x05\xc1\x1e\x9b\x1b\x8e\x02\xc2F\x92vZ\x10\u0087\x9a\xf0\xda`\u008dJ\x82\x16(\xc34\xddmW(\xc3\xd1\x06\xb4?%\xc3\xd6L\xdbB(\xc4\xfa\f\xad_ \xc5\x0e\xb4s\x11\x05ŋ\xa8~G\x05Ƴ\xbb\xf3\x81\x90\xc9\xd2/8\x04\x05\xcaT\xe5\x00\xf0@\xcbcA\xce2*\xcbn\x8a\xbe\x14\x02\xcc_Y\xae]%\xcd\xf7\xa6\xb8\xcb(\xce\xe4Q\x14\xbc\x02\xcf-\x0e\xa0\xb8(\xcf\xe7\x11\x16\xfa\xa0Џ\xe3\x1b\xea@Ыi\xfa\xc0\x05\xd1B>\x89S\x02\xd1S\xa3\xf6\x11f\xd2\x16HR'\x05\xd2\xe3\x963\xa1`\xd3\x1c\xaf\xe0 \x10\xd34/O{*Ԕt\xdc*U\xd5j\xb8\xb0j\x05\xd8\tz\x01\x1eU\u0605\x17\xfc\xe9\x02\xdau\x1e\xfe\xa4(\xda\xc1\x1fR\xd9\x02ۂ\x0e\xe7\xf7\x00ۤ\xbe\x13\xb1\x02\xde\xf2\x99\xf6\x1b\x05߬\x19]\xe6\x05\xe0`u;O\x02\xe1+y\x8e\x1d`\xe2e\x0e\v\x18\x05\xe2\xc5\x19\x9eg\xa0\xe3RdJ5\x02\xe4$\xf7\x9c[\x00\xe4\xb8\xc1;4\x05\xe5wi\xfe\f \xe6S\x17\xbb\xa7\x05\xe6\x99\x1aB\x9b\xaa\xe7B`U\xf8\x05詌\xf7,\x05\xe8\xbb\xc1:\xbc*\xe9\xc4j\xa7\x7f\x05\xea\xce攄\x90\xeb\x7f\b\xa4\x0f\x05\xeb\xba\xe9\xf1\xf7\x02\xeeLb4LU\xee\xe6\xfaPD\x99\xee\xed0\xa6\xfa(\xef\n\xec<\x94\x02\xef1e\xfa\x91\x02\xef\xfe\xbe\x9a\xdbP\xf0\xdd{\xee\x8fP\xf1\xdf\x06\xdc\"(\xf2\x92\xcd`v\x02\xf3.\x83\xe3\xf9\x05\xf3D\x16m\xb1\x02\xf3z\x03\"a\xa1\xf3\xb3D\xc8w\b\xf4\x89J\xf4` \xf4\xf8J\x9b\xec\x05\xf5Kª\x9d%\xf5\xa8\xe4\x87\xc3(\xf6\xc6[έ\x05\xf7\xb1\xed)6\x02\xfa5q\xda\xfa%\xfa;\xa0\x88\x18%\xfa_ϝ\xef\x05\xfb\x15\xf8\xe5\x96 \xfb\x99\xdePi\x92\xfb\xe6\xfca\xbd\x05\xfc\xea\x1cX\xe3\b\xfdѥ\xfd\x16(\xff\x81:\x8fX("
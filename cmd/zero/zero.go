@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func main() {
+	err := mainCmd().Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+const (
+	flagStart            = "start"
+	flagHidden           = "hidden"
+	flagIterations       = "iterations"
+	flagGames            = "games"
+	flagWorkers          = "workers"
+	flagSimulations      = "simulations"
+	flagCPuct            = "cpuct"
+	flagNoiseAlpha       = "noise-alpha"
+	flagNoiseFraction    = "noise-fraction"
+	flagTemperaturePlies = "temperature-plies"
+	flagEpochs           = "epochs"
+	flagWindow           = "window"
+	flagBatch            = "batch"
+	flagLearningRate     = "learning-rate"
+	flagSeed             = "seed"
+	flagOut              = "out"
+)
+
+func mainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `zero`,
+		Short: `Trains a policy/value network by PUCT self-play.`,
+		Long: `Trains a policy/value network by PUCT self-play.
+
+Each iteration plays games of PUCT search against itself with the current
+network, with Dirichlet noise at the root and moves sampled by visit count for
+the first plies. The network is then trained to predict the visit distribution
+and the result of the game for every position from the most recent iterations.
+
+The network is written to --out after every iteration, and the puct agent loads
+it with "puct:network=file".`,
+		RunE: runCmd,
+	}
+
+	// Take the defaults from a Zero for an empty network.
+	zero := battle.NewZero(&ttt.PolicyNetwork{})
+	trainer := zero.Trainer
+	cmd.Flags().String(flagStart, "", "a network file to continue training instead of a new network")
+	cmd.Flags().IntSlice(flagHidden, []int{64}, "the widths of the one or two hidden layers of a new network")
+	cmd.Flags().Int(flagIterations, 10, "the number of rounds of self-play and training")
+	cmd.Flags().IntP(flagGames, "n", zero.Games, "the number of self-play games per iteration")
+	cmd.Flags().Int(flagWorkers, runtime.NumCPU(), "the number of games to play in parallel")
+	cmd.Flags().Int(flagSimulations, zero.Simulations, "the number of simulations per move")
+	cmd.Flags().Float64(flagCPuct, zero.CPuct, "the scale of exploration by prior")
+	cmd.Flags().Float64(flagNoiseAlpha, zero.NoiseAlpha, "the concentration of Dirichlet noise at the root")
+	cmd.Flags().Float64(flagNoiseFraction, zero.NoiseFraction, "the weight of Dirichlet noise at the root")
+	cmd.Flags().Int(flagTemperaturePlies, zero.TemperaturePlies, "the number of plies where moves are sampled by visits")
+	cmd.Flags().Int(flagEpochs, zero.Epochs, "the number of passes over the positions per iteration")
+	cmd.Flags().Int(flagWindow, zero.Window, "the number of recent iterations to train on")
+	cmd.Flags().Int(flagBatch, trainer.BatchSize, "the number of positions per update")
+	cmd.Flags().Float64(flagLearningRate, trainer.LearningRate, "the Adam learning rate")
+	cmd.Flags().Uint64(flagSeed, zero.Seed, "the seed for initialization and self-play")
+	cmd.Flags().String(flagOut, "", "the file to write the network to after each iteration")
+	_ = cmd.MarkFlagRequired(flagOut)
+
+	return cmd
+}
+
+func runCmd(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+
+	seed, err := cmd.Flags().GetUint64(flagSeed)
+	if err != nil {
+		return err
+	}
+
+	network, err := networkFromFlags(cmd, seed)
+	if err != nil {
+		return err
+	}
+
+	zero := battle.NewZero(network)
+	zero.Seed = seed
+	for _, setting := range []struct {
+		flag  string
+		value *int
+	}{
+		{flagGames, &zero.Games},
+		{flagWorkers, &zero.Workers},
+		{flagSimulations, &zero.Simulations},
+		{flagTemperaturePlies, &zero.TemperaturePlies},
+		{flagEpochs, &zero.Epochs},
+		{flagWindow, &zero.Window},
+		{flagBatch, &zero.Trainer.BatchSize},
+	} {
+		*setting.value, err = cmd.Flags().GetInt(setting.flag)
+		if err != nil {
+			return err
+		}
+	}
+	if zero.Games < 1 || zero.Workers < 1 || zero.Simulations < 1 || zero.Window < 1 || zero.Trainer.BatchSize < 1 {
+		return errors.New("games, workers, simulations, window and batch must be positive")
+	}
+
+	for _, setting := range []struct {
+		flag  string
+		value *float64
+	}{
+		{flagCPuct, &zero.CPuct},
+		{flagNoiseAlpha, &zero.NoiseAlpha},
+		{flagNoiseFraction, &zero.NoiseFraction},
+		{flagLearningRate, &zero.Trainer.LearningRate},
+	} {
+		*setting.value, err = cmd.Flags().GetFloat64(setting.flag)
+		if err != nil {
+			return err
+		}
+	}
+
+	iterations, err := cmd.Flags().GetInt(flagIterations)
+	if err != nil {
+		return err
+	}
+	out, err := cmd.Flags().GetString(flagOut)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < iterations; i++ {
+		step := zero.Step()
+		_, _ = fmt.Fprintf(os.Stderr, "iteration %d: first mover %s, %d positions, value loss %.4f, policy loss %.4f\n",
+			step.Iteration+1, step.Result, step.Positions, step.ValueLoss, step.PolicyLoss)
+
+		err = writeNetwork(out, network)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func networkFromFlags(cmd *cobra.Command, seed uint64) (*ttt.PolicyNetwork, error) {
+	start, err := cmd.Flags().GetString(flagStart)
+	if err != nil {
+		return nil, err
+	}
+	if start != "" {
+		return ttt.LoadPolicyNetwork(start)
+	}
+
+	hidden, err := cmd.Flags().GetIntSlice(flagHidden)
+	if err != nil {
+		return nil, err
+	}
+	if len(hidden) < 1 || len(hidden) > 2 {
+		return nil, errors.New("there must be one or two hidden layers")
+	}
+	for _, width := range hidden {
+		if width < 1 || width > ttt.MaxHidden {
+			return nil, fmt.Errorf("hidden layer widths must be between 1 and %d", ttt.MaxHidden)
+		}
+	}
+
+	return ttt.NewPolicyNetwork(hidden, rand.New(rand.NewPCG(seed, 0))), nil
+}
+
+// writeNetwork replaces the file at path with network, so that an interrupted
+// run leaves the previous iteration's network.
+func writeNetwork(path string, network *ttt.PolicyNetwork) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = ttt.WritePolicyNetwork(f, network)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
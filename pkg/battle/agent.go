@@ -0,0 +1,367 @@
+package battle
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// Agent is a player which can be entered into a battle.
+type Agent interface {
+	// Name identifies the agent and its configuration.
+	Name() string
+
+	// PickMove chooses one of moves within limit, or without a time limit if
+	// limit is zero. game is from the perspective of the agent, so the agent
+	// always plays as ttt.Self.
+	PickMove(game *ttt.Game, moves []ttt.Move, limit time.Duration) ttt.Move
+}
+
+// Random plays uniformly random legal moves.
+type Random struct {
+	rng *rand.Rand
+}
+
+func NewRandom(seed uint64) *Random {
+	return &Random{rng: rand.New(rand.NewPCG(seed, 0))}
+}
+
+func (r *Random) Name() string {
+	return "random"
+}
+
+func (r *Random) PickMove(_ *ttt.Game, moves []ttt.Move, _ time.Duration) ttt.Move {
+	return moves[r.rng.IntN(len(moves))]
+}
+
+// Minimax plays the move chosen by ttt.Searcher. Under a time limit, it
+// searches to increasing depths until Margin before the limit.
+type Minimax struct {
+	Depth   int
+	Margin  time.Duration
+	Weights ttt.Weights
+
+	// Network, if set, evaluates positions instead of Weights. NetworkPath is
+	// the file it was loaded from, for the name.
+	Network     *ttt.Network
+	NetworkPath string
+
+	// Book, if set, is consulted before searching. BookPath is the file it
+	// was loaded from, for the name.
+	Book     *ttt.Book
+	BookPath string
+}
+
+func NewMinimax(depth int) *Minimax {
+	return &Minimax{Depth: depth, Margin: defaultMargin, Weights: ttt.DefaultWeights}
+}
+
+func (m *Minimax) Name() string {
+	sb := strings.Builder{}
+	_, _ = fmt.Fprintf(&sb, "minimax:depth=%d", m.Depth)
+	if m.Margin != defaultMargin {
+		_, _ = fmt.Fprintf(&sb, ",margin=%v", m.Margin)
+	}
+	for _, param := range weightParams {
+		if value := *param.field(&m.Weights); value != *param.field(&ttt.DefaultWeights) {
+			_, _ = fmt.Fprintf(&sb, ",%s=%g", param.key, value)
+		}
+	}
+	if m.Network != nil {
+		_, _ = fmt.Fprintf(&sb, ",network=%s", m.NetworkPath)
+	}
+	if m.Book != nil {
+		_, _ = fmt.Fprintf(&sb, ",book=%s", m.BookPath)
+	}
+	return sb.String()
+}
+
+func (m *Minimax) PickMove(game *ttt.Game, moves []ttt.Move, limit time.Duration) ttt.Move {
+	if m.Book != nil {
+		move, ok := m.Book.LookupSymmetric(game)
+		if ok && slices.Contains(moves, move) {
+			return move
+		}
+	}
+
+	s := ttt.NewSearcher()
+	s.Weights, s.Network = m.Weights, m.Network
+	if limit == 0 {
+		return s.PickMove(moves, game, m.Depth)
+	}
+
+	s.Deadline = time.Now().Add(limit - m.Margin)
+	choice, _ := s.IterativeDeepening(moves, game, m.Depth)
+	return choice
+}
+
+// PUCT plays the move most visited by ttt.PUCT search. Under a time limit, it
+// stops searching Margin before the limit.
+type PUCT struct {
+	Simulations int
+	CPuct       float64
+	Margin      time.Duration
+
+	Network     *ttt.PolicyNetwork
+	NetworkPath string
+
+	rng *rand.Rand
+}
+
+func NewPUCT(network *ttt.PolicyNetwork, seed uint64) *PUCT {
+	return &PUCT{
+		Simulations: ttt.DefaultSimulations,
+		CPuct:       ttt.DefaultCPuct,
+		Margin:      defaultMargin,
+		Network:     network,
+		rng:         rand.New(rand.NewPCG(seed, 0)),
+	}
+}
+
+func (p *PUCT) Name() string {
+	sb := strings.Builder{}
+	_, _ = fmt.Fprintf(&sb, "puct:simulations=%d", p.Simulations)
+	if p.CPuct != ttt.DefaultCPuct {
+		_, _ = fmt.Fprintf(&sb, ",cpuct=%g", p.CPuct)
+	}
+	if p.Margin != defaultMargin {
+		_, _ = fmt.Fprintf(&sb, ",margin=%v", p.Margin)
+	}
+	_, _ = fmt.Fprintf(&sb, ",network=%s", p.NetworkPath)
+	return sb.String()
+}
+
+func (p *PUCT) PickMove(game *ttt.Game, moves []ttt.Move, limit time.Duration) ttt.Move {
+	search := ttt.NewPUCT(p.Network, p.rng)
+	search.Simulations = p.Simulations
+	search.CPuct = p.CPuct
+	if limit != 0 {
+		search.Deadline = time.Now().Add(limit - p.Margin)
+	}
+	return search.PickMove(moves, game, 0)
+}
+
+// Skill plays at one of ttt.SkillLevels, counting from 1 for the weakest.
+// Under a time limit, it stops searching Margin before the limit.
+type Skill struct {
+	Level  int
+	Margin time.Duration
+
+	rng *rand.Rand
+}
+
+func NewSkill(level int, seed uint64) *Skill {
+	return &Skill{Level: level, Margin: defaultMargin, rng: rand.New(rand.NewPCG(seed, 0))}
+}
+
+func (k *Skill) Name() string {
+	sb := strings.Builder{}
+	_, _ = fmt.Fprintf(&sb, "skill:level=%d", k.Level)
+	if k.Margin != defaultMargin {
+		_, _ = fmt.Fprintf(&sb, ",margin=%v", k.Margin)
+	}
+	return sb.String()
+}
+
+func (k *Skill) PickMove(game *ttt.Game, moves []ttt.Move, limit time.Duration) ttt.Move {
+	s := ttt.NewSearcher()
+	if limit != 0 {
+		s.Deadline = time.Now().Add(limit - k.Margin)
+	}
+	return s.PickMoveSkill(moves, game, ttt.SkillLevels[k.Level-1], k.rng)
+}
+
+// defaultMargin is the time Minimax leaves unused under a time limit, to
+// allow for the overhead of returning a move.
+const defaultMargin = 5 * time.Millisecond
+
+// weightParams are the agent parameters which set evaluation weights.
+var weightParams = []struct {
+	key   string
+	field func(w *ttt.Weights) *float64
+}{
+	{"meta", func(w *ttt.Weights) *float64 { return &w.Meta }},
+	{"board", func(w *ttt.Weights) *float64 { return &w.Board }},
+	{"boardwin", func(w *ttt.Weights) *float64 { return &w.BoardWin }},
+	{"floor", func(w *ttt.Weights) *float64 { return &w.Floor }},
+	{"sendfree", func(w *ttt.Weights) *float64 { return &w.SendFree }},
+	{"sendboard", func(w *ttt.Weights) *float64 { return &w.SendBoard }},
+	{"sendgame", func(w *ttt.Weights) *float64 { return &w.SendGame }},
+}
+
+// ParseAgent creates an Agent from a specification of the form
+// "name[:key=value,...]", for example "random" or "minimax:depth=4".
+// The minimax agent loads evaluation weights from a file with "weights=path",
+// which individual weights like "meta=50" override, and evaluates with a
+// ttt.Network instead with "network=path". It plays from a ttt.Book before
+// searching with "book=path".
+// The puct agent requires a ttt.PolicyNetwork with "network=path", and the
+// skill agent one of ttt.SkillLevels with "level=n", counting from 1.
+// seed is used by agents which make random choices.
+func ParseAgent(spec string, seed uint64) (Agent, error) {
+	name, params, err := parseSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "random":
+		if err := checkParams(spec, params); err != nil {
+			return nil, err
+		}
+		return NewRandom(seed), nil
+	case "minimax":
+		m := NewMinimax(4)
+		if path, ok := params["weights"]; ok {
+			m.Weights, err = ttt.LoadWeights(path)
+			if err != nil {
+				return nil, fmt.Errorf("agent %q: %w", spec, err)
+			}
+			delete(params, "weights")
+		}
+		if path, ok := params["network"]; ok {
+			m.Network, err = ttt.LoadNetwork(path)
+			if err != nil {
+				return nil, fmt.Errorf("agent %q: %w", spec, err)
+			}
+			m.NetworkPath = path
+			delete(params, "network")
+		}
+		if path, ok := params["book"]; ok {
+			m.Book, err = ttt.LoadBook(path)
+			if err != nil {
+				return nil, fmt.Errorf("agent %q: %w", spec, err)
+			}
+			m.BookPath = path
+			delete(params, "book")
+		}
+		for _, param := range weightParams {
+			if value, ok := params[param.key]; ok {
+				*param.field(&m.Weights), err = strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("agent %q: parsing %s: %w", spec, param.key, err)
+				}
+				delete(params, param.key)
+			}
+		}
+		if margin, ok := params["margin"]; ok {
+			m.Margin, err = time.ParseDuration(margin)
+			if err != nil {
+				return nil, fmt.Errorf("agent %q: parsing margin: %w", spec, err)
+			}
+			delete(params, "margin")
+		}
+		if depth, ok := params["depth"]; ok {
+			m.Depth, err = strconv.Atoi(depth)
+			if err != nil {
+				return nil, fmt.Errorf("agent %q: parsing depth: %w", spec, err)
+			}
+			if m.Depth < 1 {
+				return nil, fmt.Errorf("agent %q: depth must be positive", spec)
+			}
+			delete(params, "depth")
+		}
+		if err := checkParams(spec, params); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case "puct":
+		path, ok := params["network"]
+		if !ok {
+			return nil, fmt.Errorf("agent %q: missing network", spec)
+		}
+		network, err := ttt.LoadPolicyNetwork(path)
+		if err != nil {
+			return nil, fmt.Errorf("agent %q: %w", spec, err)
+		}
+		delete(params, "network")
+
+		p := NewPUCT(network, seed)
+		p.NetworkPath = path
+		if simulations, ok := params["simulations"]; ok {
+			p.Simulations, err = strconv.Atoi(simulations)
+			if err != nil {
+				return nil, fmt.Errorf("agent %q: parsing simulations: %w", spec, err)
+			}
+			if p.Simulations < 1 {
+				return nil, fmt.Errorf("agent %q: simulations must be positive", spec)
+			}
+			delete(params, "simulations")
+		}
+		if cpuct, ok := params["cpuct"]; ok {
+			p.CPuct, err = strconv.ParseFloat(cpuct, 64)
+			if err != nil {
+				return nil, fmt.Errorf("agent %q: parsing cpuct: %w", spec, err)
+			}
+			delete(params, "cpuct")
+		}
+		if margin, ok := params["margin"]; ok {
+			p.Margin, err = time.ParseDuration(margin)
+			if err != nil {
+				return nil, fmt.Errorf("agent %q: parsing margin: %w", spec, err)
+			}
+			delete(params, "margin")
+		}
+		if err := checkParams(spec, params); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case "skill":
+		value, ok := params["level"]
+		if !ok {
+			return nil, fmt.Errorf("agent %q: missing level", spec)
+		}
+		level, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("agent %q: parsing level: %w", spec, err)
+		}
+		if level < 1 || level > len(ttt.SkillLevels) {
+			return nil, fmt.Errorf("agent %q: level must be from 1 to %d", spec, len(ttt.SkillLevels))
+		}
+		delete(params, "level")
+
+		k := NewSkill(level, seed)
+		if margin, ok := params["margin"]; ok {
+			k.Margin, err = time.ParseDuration(margin)
+			if err != nil {
+				return nil, fmt.Errorf("agent %q: parsing margin: %w", spec, err)
+			}
+			delete(params, "margin")
+		}
+		if err := checkParams(spec, params); err != nil {
+			return nil, err
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unknown agent %q", name)
+	}
+}
+
+func parseSpec(spec string) (string, map[string]string, error) {
+	name, rest, _ := strings.Cut(spec, ":")
+	params := make(map[string]string)
+	if rest == "" {
+		return name, params, nil
+	}
+
+	for _, param := range strings.Split(rest, ",") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return "", nil, fmt.Errorf("agent %q: parameter %q is not of the form key=value", spec, param)
+		}
+		params[key] = value
+	}
+
+	return name, params, nil
+}
+
+func checkParams(spec string, params map[string]string) error {
+	for key := range params {
+		return fmt.Errorf("agent %q: unknown parameter %q", spec, key)
+	}
+	return nil
+}
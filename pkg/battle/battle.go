@@ -0,0 +1,188 @@
+package battle
+
+import (
+	"fmt"
+	"time"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// Result counts the outcomes of games from the perspective of one agent.
+type Result struct {
+	Wins   int `json:"wins"`
+	Draws  int `json:"draws"`
+	Losses int `json:"losses"`
+}
+
+func (r Result) Games() int {
+	return r.Wins + r.Draws + r.Losses
+}
+
+// Points returns the points scored, counting ties as half a point.
+func (r Result) Points() float64 {
+	return float64(r.Wins) + 0.5*float64(r.Draws)
+}
+
+// Score returns the proportion of available points scored.
+func (r Result) Score() float64 {
+	if r.Games() == 0 {
+		return 0.0
+	}
+	return r.Points() / float64(r.Games())
+}
+
+// Reverse returns the Result from the perspective of the opponent.
+func (r Result) Reverse() Result {
+	return Result{Wins: r.Losses, Draws: r.Draws, Losses: r.Wins}
+}
+
+func (r Result) Add(other Result) Result {
+	return Result{
+		Wins:   r.Wins + other.Wins,
+		Draws:  r.Draws + other.Draws,
+		Losses: r.Losses + other.Losses,
+	}
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("+%d =%d -%d", r.Wins, r.Draws, r.Losses)
+}
+
+// Battle plays every opening twice between self and opponent, once with each
+// of them moving first. Returns the result for self and the record of each game.
+func Battle(self, opponent Agent, openings []Opening, tc TimeControl) (Result, []*Record) {
+	result := Result{}
+	var records []*Record
+	for i, opening := range openings {
+		for _, selfFirst := range []bool{true, false} {
+			agents := [2]Agent{self, opponent}
+			players := [2]int{0, 1}
+			selfIndex := 0
+			if !selfFirst {
+				agents = [2]Agent{opponent, self}
+				players = [2]int{1, 0}
+				selfIndex = 1
+			}
+
+			record := battle(agents, opening, tc)
+			record.Opening = i
+			record.Players = players
+			result = result.Add(record.Result(selfIndex))
+			records = append(records, record)
+		}
+	}
+
+	return result, records
+}
+
+// battle runs a battle between agents, starting after the moves in opening
+// have been played. agents[0] moves first.
+func battle(agents [2]Agent, opening Opening, tc TimeControl) *Record {
+	record := &Record{
+		Agents:       [2]string{agents[0].Name(), agents[1].Name()},
+		OpeningPlies: len(opening),
+		Winner:       -1,
+		Termination:  Draw,
+	}
+
+	s := newState()
+	for _, move := range opening {
+		record.Moves = append(record.Moves, move)
+		if s.play(move) {
+			record.Winner = 1 - s.toMove()
+			record.Termination = Win
+			return record
+		}
+	}
+
+	clocks := [2]*clock{newClock(tc), newClock(tc)}
+	moves := make([]ttt.Move, 81)
+	for {
+		nMoves := s.legalMoves(moves)
+		if nMoves == 0 {
+			return record
+		}
+		if s.drawn() {
+			record.Termination = Dead
+			return record
+		}
+
+		player := s.toMove()
+		limit := clocks[player].limit()
+
+		start := time.Now()
+		choice := agents[player].PickMove(s.games[player], moves[:nMoves], limit)
+		used := time.Since(start)
+
+		record.Times = append(record.Times, used)
+		record.Limits = append(record.Limits, limit)
+		if !clocks[player].use(used) {
+			record.Winner = 1 - player
+			record.Termination = Timeout
+			return record
+		}
+
+		record.Moves = append(record.Moves, choice)
+		if s.validate(choice) != nil {
+			record.Winner = 1 - player
+			record.Termination = Illegal
+			return record
+		}
+
+		if s.play(choice) {
+			record.Winner = player
+			record.Termination = Win
+			return record
+		}
+	}
+}
+
+// state tracks a game between two players, indexed by the order they move in.
+type state struct {
+	// games holds the game from the perspective of each player.
+	games [2]*ttt.Game
+
+	ply int
+}
+
+func newState() *state {
+	return &state{games: [2]*ttt.Game{ttt.NewGame(), ttt.NewGame()}}
+}
+
+// toMove returns the index of the player whose turn it is.
+func (s *state) toMove() int {
+	return s.ply % 2
+}
+
+// play makes move for the player whose turn it is.
+// Returns true if the move won the game.
+func (s *state) play(move ttt.Move) bool {
+	player := s.toMove()
+	a, b, x, y := move.XBoard(), move.YBoard(), move.XCell(), move.YCell()
+
+	isWin, _ := s.games[player].WithMove(a, b, x, y, ttt.Self)
+	s.games[1-player].WithMove(a, b, x, y, ttt.Opponent)
+
+	s.ply++
+	return isWin
+}
+
+// forced returns the index, counting along rows from the top left, of the
+// board the player to move must play in, or ttt.Anywhere.
+func (s *state) forced() int {
+	return s.games[0].Forced()
+}
+
+// drawn returns true if neither player can win any more.
+func (s *state) drawn() bool {
+	return s.games[0].Drawn()
+}
+
+// validate returns an error if the player whose turn it is may not play move.
+func (s *state) validate(move ttt.Move) error {
+	return s.games[0].Validate(move)
+}
+
+// legalMoves writes the moves available to the player whose turn it is to out.
+func (s *state) legalMoves(out []ttt.Move) int {
+	return s.games[0].Moves(out)
+}
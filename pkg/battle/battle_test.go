@@ -0,0 +1,103 @@
+package battle_test
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestBattle(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	openings, err := battle.RandomOpenings(rng, 5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, records := battle.Battle(battle.NewMinimax(2), battle.NewRandom(1), openings, battle.TimeControl{})
+	if len(records) != 10 {
+		t.Errorf("got %d records, want 10", len(records))
+	}
+	if got.Games() != 10 {
+		t.Errorf("got %d games, want 10", got.Games())
+	}
+	if got.Score() < 0.7 {
+		t.Errorf("got %v against random agent, want score at least 0.7", got)
+	}
+}
+
+func TestParseAgent(t *testing.T) {
+	tt := []struct {
+		spec     string
+		wantName string
+		wantErr  bool
+	}{
+		{spec: "random", wantName: "random"},
+		{spec: "minimax", wantName: "minimax:depth=4"},
+		{spec: "minimax:depth=2", wantName: "minimax:depth=2"},
+		{spec: "minimax:depth=2,margin=10ms", wantName: "minimax:depth=2,margin=10ms"},
+		{spec: "minimax:margin=10", wantErr: true},
+		{spec: "minimax:depth=3,meta=50,boardwin=2.5", wantName: "minimax:depth=3,meta=50,boardwin=2.5"},
+		{spec: "minimax:meta=100", wantName: "minimax:depth=4"},
+		{spec: "minimax:meta=high", wantErr: true},
+		{spec: "minimax:depth=0", wantErr: true},
+		{spec: "minimax:width=2", wantErr: true},
+		{spec: "minimax:depth", wantErr: true},
+		{spec: "random:depth=2", wantErr: true},
+		{spec: "mcts", wantErr: true},
+		{spec: "puct", wantErr: true},
+		{spec: "puct:network=missing.json", wantErr: true},
+		{spec: "skill:level=3", wantName: "skill:level=3"},
+		{spec: "skill:level=8,margin=10ms", wantName: "skill:level=8,margin=10ms"},
+		{spec: "skill", wantErr: true},
+		{spec: "skill:level=0", wantErr: true},
+		{spec: "skill:level=9", wantErr: true},
+		{spec: "skill:level=2,depth=3", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.spec, func(t *testing.T) {
+			got, err := battle.ParseAgent(tc.spec, 1)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("got agent %q, want error", got.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Name() != tc.wantName {
+				t.Errorf("got name %q, want %q", got.Name(), tc.wantName)
+			}
+		})
+	}
+}
+
+// illegal plays into the first cell, whether or not it is legal.
+type illegal struct{}
+
+func (illegal) Name() string {
+	return "illegal"
+}
+
+func (illegal) PickMove(_ *ttt.Game, _ []ttt.Move, _ time.Duration) ttt.Move {
+	return ttt.ToMove(0, 0, 0, 0)
+}
+
+func TestBattle_Illegal(t *testing.T) {
+	_, records := battle.Battle(illegal{}, battle.NewRandom(1), []battle.Opening{nil}, battle.TimeControl{})
+
+	for _, record := range records {
+		selfIndex := 0
+		if record.Agents[1] == "illegal" {
+			selfIndex = 1
+		}
+
+		if record.Termination != battle.Illegal || record.Winner == selfIndex {
+			t.Errorf("got %v for %d after %v, want loss by illegal move", record.Termination, record.Winner, record.Moves)
+		}
+	}
+}
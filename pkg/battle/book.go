@@ -0,0 +1,73 @@
+package battle
+
+import (
+	"slices"
+	"time"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// BookPosition is a position reached in recorded games.
+type BookPosition struct {
+	// Moves reach the position from the start of the game.
+	Moves []ttt.Move
+
+	// Count is the number of games which reached the position.
+	Count int
+}
+
+// BookPositions returns the positions reached in records before plies moves,
+// keyed by the hash the book looks them up by. If symmetric, positions which
+// are the same up to symmetry are counted together, keyed by their canonical
+// hash.
+func BookPositions(records []*Record, plies int, symmetric bool) map[uint64]*BookPosition {
+	positions := make(map[uint64]*BookPosition)
+	moves := make([]ttt.Move, 81)
+	for _, record := range records {
+		s := newState()
+		for ply, move := range record.Moves {
+			if ply >= plies {
+				break
+			}
+
+			nMoves := s.legalMoves(moves)
+			hash, _ := bookHash(s, symmetric)
+			if position, ok := positions[hash]; ok {
+				position.Count++
+			} else {
+				positions[hash] = &BookPosition{Moves: slices.Clone(record.Moves[:ply]), Count: 1}
+			}
+
+			if !slices.Contains(moves[:nMoves], move) || s.play(move) {
+				break
+			}
+		}
+	}
+	return positions
+}
+
+// bookHash returns the hash of the position in s for the player to move. If
+// symmetric, it is the canonical hash, and the symmetry which maps the position
+// to its canonical form is also returned.
+func bookHash(s *state, symmetric bool) (uint64, ttt.Symmetry) {
+	game := s.games[s.toMove()]
+	if symmetric {
+		return game.CanonicalHash()
+	}
+	return game.Hash(), ttt.Identity
+}
+
+// BookMove returns the hash of the position reached by moves and the move
+// agent chooses in it within limit. If symmetric, the hash is the canonical
+// hash, and the move is mapped to the canonical form of the position.
+func BookMove(agent Agent, moves []ttt.Move, limit time.Duration, symmetric bool) (uint64, ttt.Move) {
+	s := newState()
+	for _, move := range moves {
+		s.play(move)
+	}
+
+	legal := make([]ttt.Move, 81)
+	nMoves := s.legalMoves(legal)
+	choice := agent.PickMove(s.games[s.toMove()], legal[:nMoves], limit)
+	hash, symmetry := bookHash(s, symmetric)
+	return hash, symmetry.Move(choice)
+}
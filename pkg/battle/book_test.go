@@ -0,0 +1,84 @@
+package battle_test
+
+import (
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestBookPositions(t *testing.T) {
+	records := []*battle.Record{
+		{Moves: []ttt.Move{ttt.FromRowCol(4, 4), ttt.FromRowCol(3, 3), ttt.FromRowCol(0, 0)}},
+		{Moves: []ttt.Move{ttt.FromRowCol(4, 4), ttt.FromRowCol(5, 5)}},
+	}
+
+	positions := battle.BookPositions(records, 2, false)
+
+	// The start, and the position after 4 4, are reached by both games.
+	// Both replies to 4 4 are at the second ply.
+	if len(positions) != 2 {
+		t.Fatalf("got %d positions, want 2", len(positions))
+	}
+	for _, position := range positions {
+		if position.Count != 2 {
+			t.Errorf("position %q: got count %d, want 2", ttt.FormatMoves(position.Moves), position.Count)
+		}
+	}
+}
+
+func TestBookPositions_Symmetric(t *testing.T) {
+	// The replies 3 3 and 5 5 to 4 4 are the same up to symmetry.
+	records := []*battle.Record{
+		{Moves: []ttt.Move{ttt.FromRowCol(4, 4), ttt.FromRowCol(3, 3), ttt.FromRowCol(0, 0)}},
+		{Moves: []ttt.Move{ttt.FromRowCol(4, 4), ttt.FromRowCol(5, 5), ttt.FromRowCol(8, 8)}},
+	}
+
+	if got := len(battle.BookPositions(records, 3, false)); got != 4 {
+		t.Errorf("got %d positions, want 4", got)
+	}
+
+	positions := battle.BookPositions(records, 3, true)
+	if len(positions) != 3 {
+		t.Fatalf("got %d positions up to symmetry, want 3", len(positions))
+	}
+	for _, position := range positions {
+		if position.Count != 2 {
+			t.Errorf("position %q: got count %d, want 2", ttt.FormatMoves(position.Moves), position.Count)
+		}
+	}
+}
+
+func TestMinimax_Book(t *testing.T) {
+	// Book a poor first move, which the minimax agent plays without search.
+	want := ttt.FromRowCol(0, 0)
+	hash, _ := battle.BookMove(battle.NewRandom(1), nil, 0, false)
+	minimax := battle.NewMinimax(2)
+	minimax.Book = ttt.BuildBook(map[uint64]ttt.Move{hash: want})
+
+	hash, got := battle.BookMove(minimax, nil, 0, false)
+	if got != want {
+		t.Errorf("got %v, want book move %v", got, want)
+	}
+
+	if _, ok := minimax.Book.Lookup(hash); !ok {
+		t.Error("BookMove returned a hash not in the book")
+	}
+}
+
+func TestMinimax_SymmetricBook(t *testing.T) {
+	// Book a random reply after 4 4 3 3, which the minimax agent also plays
+	// after 4 4 5 5.
+	opening := []ttt.Move{ttt.FromRowCol(4, 4), ttt.FromRowCol(3, 3)}
+	hash, want := battle.BookMove(battle.NewRandom(1), opening, 0, true)
+	minimax := battle.NewMinimax(2)
+	minimax.Book = ttt.BuildBook(map[uint64]ttt.Move{hash: want})
+
+	symmetric := []ttt.Move{ttt.FromRowCol(4, 4), ttt.FromRowCol(5, 5)}
+	gotHash, got := battle.BookMove(minimax, symmetric, 0, true)
+	if gotHash != hash {
+		t.Errorf("got hash %x, want the same canonical hash %x", gotHash, hash)
+	}
+	if got != want {
+		t.Errorf("got %v, want book move %v in the canonical position", got, want)
+	}
+}
@@ -0,0 +1,191 @@
+package battle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeControl limits the time agents may spend choosing moves. An agent which
+// exceeds its limit loses on time. The zero TimeControl has no limits.
+type TimeControl struct {
+	// First is the limit for each agent's first move. If zero, PerMove is used.
+	First time.Duration
+
+	// PerMove is the limit for each move.
+	PerMove time.Duration
+
+	// Base is the total time each agent has for the game. If set, PerMove and
+	// First are ignored and the limit for each move is the time remaining.
+	Base time.Duration
+
+	// Increment is added to an agent's remaining time after each of its moves.
+	Increment time.Duration
+}
+
+// CodinGame is the time control of the CodinGame competition.
+var CodinGame = TimeControl{First: 1000 * time.Millisecond, PerMove: 100 * time.Millisecond}
+
+// ParseTimeControl parses a time control in one of the forms:
+//
+//	"100ms"        every move must take at most 100ms
+//	"1000ms/100ms" the first move may take 1000ms, and later moves 100ms
+//	"60s+1s"       each agent has 60s for the game, plus 1s per move
+//	"codingame"    the same as "1000ms/100ms"
+//
+// The empty string means no time control.
+func ParseTimeControl(s string) (TimeControl, error) {
+	switch {
+	case s == "":
+		return TimeControl{}, nil
+	case s == "codingame":
+		return CodinGame, nil
+	case strings.Contains(s, "+"):
+		base, increment, _ := strings.Cut(s, "+")
+		tc := TimeControl{}
+		var err error
+		tc.Base, err = parsePositiveDuration(base)
+		if err != nil {
+			return TimeControl{}, fmt.Errorf("time control %q: %w", s, err)
+		}
+		tc.Increment, err = time.ParseDuration(increment)
+		if err != nil || tc.Increment < 0 {
+			return TimeControl{}, fmt.Errorf("time control %q: invalid increment %q", s, increment)
+		}
+		return tc, nil
+	case strings.Contains(s, "/"):
+		first, perMove, _ := strings.Cut(s, "/")
+		tc := TimeControl{}
+		var err error
+		tc.First, err = parsePositiveDuration(first)
+		if err != nil {
+			return TimeControl{}, fmt.Errorf("time control %q: %w", s, err)
+		}
+		tc.PerMove, err = parsePositiveDuration(perMove)
+		if err != nil {
+			return TimeControl{}, fmt.Errorf("time control %q: %w", s, err)
+		}
+		return tc, nil
+	default:
+		perMove, err := parsePositiveDuration(s)
+		if err != nil {
+			return TimeControl{}, fmt.Errorf("time control %q: %w", s, err)
+		}
+		return TimeControl{PerMove: perMove}, nil
+	}
+}
+
+func parsePositiveDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", s)
+	}
+	return d, nil
+}
+
+func (tc TimeControl) String() string {
+	switch {
+	case tc.Base != 0:
+		return fmt.Sprintf("%v+%v", tc.Base, tc.Increment)
+	case tc.First != 0:
+		return fmt.Sprintf("%v/%v", tc.First, tc.PerMove)
+	case tc.PerMove != 0:
+		return tc.PerMove.String()
+	default:
+		return ""
+	}
+}
+
+// clock tracks the time an agent has left under a TimeControl.
+type clock struct {
+	tc        TimeControl
+	remaining time.Duration
+	moves     int
+}
+
+func newClock(tc TimeControl) *clock {
+	return &clock{tc: tc, remaining: tc.Base}
+}
+
+// limit returns the time the agent may use for its next move, or zero if there
+// is no limit.
+func (c *clock) limit() time.Duration {
+	switch {
+	case c.tc.Base != 0:
+		return c.remaining
+	case c.moves == 0 && c.tc.First != 0:
+		return c.tc.First
+	default:
+		return c.tc.PerMove
+	}
+}
+
+// use records that the agent spent used on a move.
+// Returns false if the agent ran out of time.
+func (c *clock) use(used time.Duration) bool {
+	limit := c.limit()
+	c.moves++
+	if c.tc.Base != 0 {
+		c.remaining += c.tc.Increment - used
+	}
+	return limit == 0 || used <= limit
+}
+
+// closeFraction is the proportion of the time limit above which a move is
+// considered close to timing out.
+const closeFraction = 0.9
+
+// Timing summarizes the time an agent used choosing moves.
+type Timing struct {
+	Moves int
+	Total time.Duration
+	Max   time.Duration
+
+	// MaxFraction is the largest proportion of the limit used for a move.
+	MaxFraction float64
+
+	// Close is the number of moves which used more than 90% of the limit.
+	Close int
+
+	Timeouts int
+}
+
+func (t *Timing) Mean() time.Duration {
+	if t.Moves == 0 {
+		return 0
+	}
+	return t.Total / time.Duration(t.Moves)
+}
+
+// Timings summarizes the time used by each of n agents in records, by their
+// index in Record.Players.
+func Timings(records []*Record, n int) []Timing {
+	timings := make([]Timing, n)
+	for _, record := range records {
+		for i, used := range record.Times {
+			player := record.mover(record.OpeningPlies + i)
+			timing := &timings[record.Players[player]]
+
+			timing.Moves++
+			timing.Total += used
+			timing.Max = max(timing.Max, used)
+
+			if limit := record.Limits[i]; limit != 0 {
+				fraction := float64(used) / float64(limit)
+				timing.MaxFraction = max(timing.MaxFraction, fraction)
+				if fraction > closeFraction {
+					timing.Close++
+				}
+			}
+		}
+
+		if record.Termination == Timeout {
+			timings[record.Players[1-record.Winner]].Timeouts++
+		}
+	}
+
+	return timings
+}
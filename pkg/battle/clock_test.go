@@ -0,0 +1,142 @@
+package battle_test
+
+import (
+	"testing"
+	"time"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tt := []struct {
+		input   string
+		want    battle.TimeControl
+		wantErr bool
+	}{
+		{input: "", want: battle.TimeControl{}},
+		{input: "100ms", want: battle.TimeControl{PerMove: 100 * time.Millisecond}},
+		{input: "codingame", want: battle.CodinGame},
+		{input: "1s/100ms", want: battle.TimeControl{First: time.Second, PerMove: 100 * time.Millisecond}},
+		{input: "60s+1s", want: battle.TimeControl{Base: time.Minute, Increment: time.Second}},
+		{input: "60s+0s", want: battle.TimeControl{Base: time.Minute}},
+		{input: "100", wantErr: true},
+		{input: "0s", wantErr: true},
+		{input: "-1s/100ms", wantErr: true},
+		{input: "60s+-1s", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := battle.ParseTimeControl(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// slow sleeps for a fixed time before every move after its first.
+type slow struct {
+	battle.Random
+	delay time.Duration
+	moves int
+}
+
+func (s *slow) Name() string {
+	return "slow"
+}
+
+func (s *slow) PickMove(game *ttt.Game, moves []ttt.Move, limit time.Duration) ttt.Move {
+	if s.moves > 0 {
+		time.Sleep(s.delay)
+	}
+	s.moves++
+	return s.Random.PickMove(game, moves, limit)
+}
+
+func TestBattle_Timeout(t *testing.T) {
+	tt := []struct {
+		name            string
+		tc              battle.TimeControl
+		wantTermination battle.Termination
+	}{
+		{
+			name:            "per move",
+			tc:              battle.TimeControl{PerMove: 5 * time.Millisecond},
+			wantTermination: battle.Timeout,
+		},
+		{
+			name:            "first move allowance",
+			tc:              battle.TimeControl{First: time.Second, PerMove: 5 * time.Millisecond},
+			wantTermination: battle.Timeout,
+		},
+		{
+			name:            "clock",
+			tc:              battle.TimeControl{Base: 15 * time.Millisecond},
+			wantTermination: battle.Timeout,
+		},
+		{
+			name: "increment",
+			tc:   battle.TimeControl{Base: 15 * time.Millisecond, Increment: 50 * time.Millisecond},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			self := &slow{Random: *battle.NewRandom(1), delay: 10 * time.Millisecond}
+			_, records := battle.Battle(self, battle.NewRandom(2), []battle.Opening{nil}, tc.tc)
+
+			for _, record := range records {
+				selfIndex := 0
+				if record.Agents[1] == "slow" {
+					selfIndex = 1
+				}
+
+				if tc.wantTermination != battle.Timeout {
+					if record.Termination == battle.Timeout {
+						t.Errorf("got timeout after %v, want none", record.Moves)
+					}
+					continue
+				}
+
+				if record.Termination != battle.Timeout || record.Winner == selfIndex {
+					t.Errorf("got %v for %d, want loss on time", record.Termination, record.Winner)
+				}
+				if got := len(record.Times); got != len(record.Limits) {
+					t.Errorf("got %d times and %d limits", got, len(record.Limits))
+				}
+			}
+
+			timings := battle.Timings(records, 2)
+			if timings[0].Max < self.delay {
+				t.Errorf("got maximum time %v, want at least %v", timings[0].Max, self.delay)
+			}
+		})
+	}
+}
+
+func TestTimings_SameName(t *testing.T) {
+	// Both agents are named "slow", but only the first sleeps.
+	self := &slow{Random: *battle.NewRandom(1), delay: 5 * time.Millisecond}
+	opponent := &slow{Random: *battle.NewRandom(2)}
+	_, records := battle.Battle(self, opponent, []battle.Opening{nil}, battle.TimeControl{})
+
+	timings := battle.Timings(records, 2)
+	if timings[0].Max < self.delay || timings[1].Max >= self.delay {
+		t.Errorf("got maximum times %v and %v, want the first at least %v and the second less",
+			timings[0].Max, timings[1].Max, self.delay)
+	}
+	if got, want := timings[0].Moves+timings[1].Moves, len(records[0].Times)+len(records[1].Times); got != want {
+		t.Errorf("got %d moves timed, want %d", got, want)
+	}
+}
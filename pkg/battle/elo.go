@@ -0,0 +1,99 @@
+package battle
+
+import (
+	"math"
+)
+
+const (
+	// eloPrior is the number of virtual draws added between each pair of agents
+	// which played each other, so agents which won or lost every game still
+	// have finite ratings.
+	eloPrior = 2.0
+
+	eloIterations = 10000
+	eloTolerance  = 1e-9
+)
+
+// FitElo computes Elo ratings for every agent in the crosstable jointly over all
+// games by fitting a Bradley-Terry model, counting draws as half a win for each
+// side. Ratings are relative, with a mean of zero.
+func FitElo(table Crosstable) []float64 {
+	n := len(table)
+
+	// Find the maximum likelihood strengths with the MM algorithm described in
+	// Hunter, "MM algorithms for generalized Bradley-Terry models" (2004).
+	wins := make([]float64, n)
+	games := make([][]float64, n)
+	for i := range table {
+		games[i] = make([]float64, n)
+		for j, result := range table[i] {
+			if i == j || result.Games() == 0 {
+				continue
+			}
+			wins[i] += result.Points() + eloPrior/2
+			games[i][j] = float64(result.Games()) + eloPrior
+		}
+	}
+
+	gamma := make([]float64, n)
+	for i := range gamma {
+		gamma[i] = 1.0
+	}
+
+	next := make([]float64, n)
+	for iteration := 0; iteration < eloIterations; iteration++ {
+		for i := range next {
+			denominator := 0.0
+			for j, g := range games[i] {
+				if g != 0 {
+					denominator += g / (gamma[i] + gamma[j])
+				}
+			}
+
+			if denominator == 0 {
+				next[i] = gamma[i]
+			} else {
+				next[i] = wins[i] / denominator
+			}
+		}
+
+		normalize(next)
+
+		change := 0.0
+		for i := range gamma {
+			change = math.Max(change, math.Abs(next[i]-gamma[i]))
+		}
+		gamma, next = next, gamma
+
+		if change < eloTolerance {
+			break
+		}
+	}
+
+	ratings := make([]float64, n)
+	for i, g := range gamma {
+		ratings[i] = 400 * math.Log10(g)
+	}
+
+	return ratings
+}
+
+// normalize scales gamma to have a geometric mean of one.
+func normalize(gamma []float64) {
+	sumLog := 0.0
+	for _, g := range gamma {
+		sumLog += math.Log(g)
+	}
+
+	scale := math.Exp(-sumLog / float64(len(gamma)))
+	for i := range gamma {
+		gamma[i] *= scale
+	}
+}
+
+// EloDifference returns the Elo difference implied by scoring score against an
+// opponent, for example 0.75 implies roughly +191.
+func EloDifference(score float64) float64 {
+	score = math.Min(math.Max(score, 1e-3), 1-1e-3)
+	return 400 * math.Log10(score/(1-score))
+}
@@ -0,0 +1,73 @@
+package battle_test
+
+import (
+	"math"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+)
+
+func TestFitElo(t *testing.T) {
+	tt := []struct {
+		name        string
+		table       battle.Crosstable
+		wantRatings []float64
+	}{
+		{
+			name: "even",
+			table: battle.Crosstable{
+				{{}, {Wins: 5, Losses: 5}},
+				{{Wins: 5, Losses: 5}, {}},
+			},
+			wantRatings: []float64{0, 0},
+		},
+		{
+			name: "two agents",
+			// With the prior of two draws, A scores 17/20.
+			table: battle.Crosstable{
+				{{}, {Wins: 15, Draws: 2, Losses: 1}},
+				{{Wins: 1, Draws: 2, Losses: 15}, {}},
+			},
+			wantRatings: []float64{
+				battle.EloDifference(0.85) / 2,
+				-battle.EloDifference(0.85) / 2,
+			},
+		},
+		{
+			name: "perfect score is finite",
+			table: battle.Crosstable{
+				{{}, {Wins: 10}},
+				{{Losses: 10}, {}},
+			},
+			wantRatings: []float64{
+				battle.EloDifference(11.0/12.0) / 2,
+				-battle.EloDifference(11.0/12.0) / 2,
+			},
+		},
+		{
+			name: "transitive",
+			table: battle.Crosstable{
+				{{}, {Wins: 8, Losses: 2}, {}},
+				{{Wins: 2, Losses: 8}, {}, {Wins: 8, Losses: 2}},
+				{{}, {Wins: 2, Losses: 8}, {}},
+			},
+			wantRatings: []float64{
+				battle.EloDifference(0.75),
+				0,
+				-battle.EloDifference(0.75),
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := battle.FitElo(tc.table)
+
+			for i := range got {
+				if math.Abs(got[i]-tc.wantRatings[i]) > 0.01 {
+					t.Errorf("got ratings %v, want %v", got, tc.wantRatings)
+					break
+				}
+			}
+		})
+	}
+}
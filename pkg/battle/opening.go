@@ -0,0 +1,117 @@
+package battle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// Opening is a sequence of moves played before agents take over, so that
+// deterministic agents do not play the same game every time.
+type Opening []ttt.Move
+
+func (o Opening) String() string {
+	return ttt.FormatMoves(o)
+}
+
+// maxOpeningTries is the most random openings RandomOpenings generates for
+// each one it returns, before giving up.
+const maxOpeningTries = 100
+
+// RandomOpenings generates n openings of plies uniformly random legal moves.
+// Openings which end the game are discarded. Returns an error if plies is
+// negative, or if so many openings end the game that one cannot be found.
+func RandomOpenings(rng *rand.Rand, n, plies int) ([]Opening, error) {
+	if plies < 0 {
+		return nil, fmt.Errorf("negative number of plies %d", plies)
+	}
+
+	openings := make([]Opening, n)
+	for i := range openings {
+		ok := false
+		for try := 0; !ok; try++ {
+			if try == maxOpeningTries {
+				return nil, fmt.Errorf("no opening of %d random plies in %d tries left the game open", plies, maxOpeningTries)
+			}
+			openings[i], ok = randomOpening(rng, plies)
+		}
+	}
+
+	return openings, nil
+}
+
+// randomOpening plays plies random moves. Returns false if the game ended
+// before all plies were played.
+func randomOpening(rng *rand.Rand, plies int) (Opening, bool) {
+	s := newState()
+	moves := make([]ttt.Move, 81)
+
+	opening := make(Opening, plies)
+	for i := range opening {
+		nMoves := s.legalMoves(moves)
+		if nMoves == 0 {
+			return nil, false
+		}
+
+		opening[i] = moves[rng.IntN(nMoves)]
+		if s.play(opening[i]) {
+			return nil, false
+		}
+	}
+
+	return opening, true
+}
+
+// ReadOpenings reads openings from r, one per line, each as a move list in the
+// format read by ttt.ParseMoves. Blank lines and lines beginning with '#' are
+// ignored.
+func ReadOpenings(r io.Reader) ([]Opening, error) {
+	var openings []Opening
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		moves, err := ttt.ParseMoves(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		opening := Opening(moves)
+		err = opening.validate()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		openings = append(openings, opening)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return openings, nil
+}
+
+// validate checks that every move in the opening is legal, and that the game
+// is not over by the end of it.
+func (o Opening) validate() error {
+	s := newState()
+	for _, move := range o {
+		if err := s.validate(move); err != nil {
+			return fmt.Errorf("after %q: %w", o[:s.ply], err)
+		}
+
+		if s.play(move) {
+			return fmt.Errorf("opening %q ends the game", o)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package battle_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestReadOpenings(t *testing.T) {
+	tt := []struct {
+		name         string
+		input        string
+		wantOpenings []battle.Opening
+		wantErr      bool
+	}{
+		{
+			name:         "empty",
+			input:        "",
+			wantOpenings: nil,
+		},
+		{
+			name:  "comments and blank lines",
+			input: "# openings\n\n4 4\n4 4 3 3\n",
+			wantOpenings: []battle.Opening{
+				{ttt.FromRowCol(4, 4)},
+				{ttt.FromRowCol(4, 4), ttt.FromRowCol(3, 3)},
+			},
+		},
+		{
+			name:    "odd coordinates",
+			input:   "4 4 3\n",
+			wantErr: true,
+		},
+		{
+			name:    "out of range",
+			input:   "4 9\n",
+			wantErr: true,
+		},
+		{
+			name:    "wrong board",
+			input:   "4 4 0 0\n",
+			wantErr: true,
+		},
+		{
+			name:    "cell taken",
+			input:   "4 4 4 4\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := battle.ReadOpenings(strings.NewReader(tc.input))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("got openings %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.wantOpenings, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRandomOpenings(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	openings, err := battle.RandomOpenings(rng, 20, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opening := range openings {
+		if len(opening) != 8 {
+			t.Errorf("got %d plies in %q, want 8", len(opening), opening)
+		}
+
+		// Generated openings must be readable as opening files.
+		_, err = battle.ReadOpenings(strings.NewReader(opening.String()))
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Random games almost never last every ply, so the retries run out.
+	for _, plies := range []int{-1, 81} {
+		if _, err := battle.RandomOpenings(rng, 1, plies); err == nil {
+			t.Errorf("got no error for %d plies", plies)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package battle
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// Termination is the reason a game ended.
+type Termination int
+
+const (
+	// Win means a player won the meta-board.
+	Win Termination = iota
+	// Draw means no legal moves remained and nobody won.
+	Draw
+	// Timeout means a player exceeded their time limit and lost.
+	Timeout
+	// Illegal means a player chose an illegal move and lost.
+	Illegal
+	// Dead means moves remained, but neither player could win the meta-board.
+	Dead
+)
+
+var terminations = []string{"win", "draw", "timeout", "illegal", "dead"}
+
+func (t Termination) String() string {
+	if int(t) < len(terminations) {
+		return terminations[t]
+	}
+	return "unknown"
+}
+
+func (t Termination) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Termination) UnmarshalText(text []byte) error {
+	for i, name := range terminations {
+		if string(text) == name {
+			*t = Termination(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown termination %q", text)
+}
+
+// Record is a finished game.
+type Record struct {
+	// Agents holds the names of the agents in the order they moved, and
+	// Players the index of each among the agents battled: 0 for self and 1
+	// for opponent in Battle, or the index into the agents of a Tournament.
+	// Agents with the same name are told apart by Players.
+	Agents  [2]string
+	Players [2]int
+
+	// Opening is the index of the opening the game started from, and Seed the
+	// seed used to generate openings and seed random agents.
+	Opening int
+	Seed    uint64
+
+	// Moves holds every move of the game, including those of the opening. If
+	// the game ended with an illegal move, it is the last move.
+	Moves []ttt.Move
+
+	// OpeningPlies is the number of moves at the start of Moves which were
+	// played from the opening rather than chosen by the agents.
+	OpeningPlies int
+
+	// Times holds the time taken to choose each move after the opening, and
+	// Limits the time the agent was allowed for it, or zero if unlimited.
+	Times  []time.Duration
+	Limits []time.Duration
+
+	// Winner is the index into Agents of the winner, or -1 if the game was a
+	// draw.
+	Winner int
+
+	Termination Termination
+}
+
+// Result returns the outcome of the game for the agent at index player of
+// Agents.
+func (r *Record) Result(player int) Result {
+	switch r.Winner {
+	case -1:
+		return Result{Draws: 1}
+	case player:
+		return Result{Wins: 1}
+	default:
+		return Result{Losses: 1}
+	}
+}
+
+// Score returns the result in the conventional notation, from the perspective
+// of the agent which moved first.
+func (r *Record) Score() string {
+	switch r.Winner {
+	case -1:
+		return "1/2-1/2"
+	case 0:
+		return "1-0"
+	default:
+		return "0-1"
+	}
+}
+
+// mover returns the index into Agents of the agent which played Moves[i].
+func (r *Record) mover(i int) int {
+	return i % 2
+}
+
+// Game returns the game after the first plies moves, from the perspective of
+// the agent which moved first.
+func (r *Record) Game(plies int) *ttt.Game {
+	s := newState()
+	for _, move := range r.Moves[:plies] {
+		s.play(move)
+	}
+	return s.games[0]
+}
+
+// recordJSON is the format Records are stored in.
+type recordJSON struct {
+	Agents       [2]string   `json:"agents"`
+	Players      [2]int      `json:"players"`
+	Opening      int         `json:"opening"`
+	Seed         uint64      `json:"seed"`
+	Moves        string      `json:"moves"`
+	OpeningPlies int         `json:"opening_plies"`
+	TimesMs      []float64   `json:"times_ms,omitempty"`
+	LimitsMs     []float64   `json:"limits_ms,omitempty"`
+	Result       string      `json:"result"`
+	Termination  Termination `json:"termination"`
+}
+
+func (r *Record) MarshalJSON() ([]byte, error) {
+	return json.Marshal(recordJSON{
+		Agents:       r.Agents,
+		Players:      r.Players,
+		Opening:      r.Opening,
+		Seed:         r.Seed,
+		Moves:        ttt.FormatMoves(r.Moves),
+		OpeningPlies: r.OpeningPlies,
+		TimesMs:      toMilliseconds(r.Times),
+		LimitsMs:     toMilliseconds(r.Limits),
+		Result:       r.Score(),
+		Termination:  r.Termination,
+	})
+}
+
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var j recordJSON
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+
+	moves, err := ttt.ParseMoves(j.Moves)
+	if err != nil {
+		return err
+	}
+	err = validateMoves(moves, j.Termination)
+	if err != nil {
+		return err
+	}
+
+	var winner int
+	switch j.Result {
+	case "1-0":
+		winner = 0
+	case "0-1":
+		winner = 1
+	case "1/2-1/2":
+		winner = -1
+	default:
+		return fmt.Errorf("unknown result %q", j.Result)
+	}
+
+	*r = Record{
+		Agents:       j.Agents,
+		Players:      j.Players,
+		Opening:      j.Opening,
+		Seed:         j.Seed,
+		Moves:        moves,
+		OpeningPlies: j.OpeningPlies,
+		Times:        fromMilliseconds(j.TimesMs),
+		Limits:       fromMilliseconds(j.LimitsMs),
+		Winner:       winner,
+		Termination:  j.Termination,
+	}
+	return nil
+}
+
+// validateMoves checks that every move of a game is legal, except the last if
+// the game ended with an illegal move.
+func validateMoves(moves []ttt.Move, termination Termination) error {
+	if termination == Illegal && len(moves) > 0 {
+		moves = moves[:len(moves)-1]
+	}
+
+	s := newState()
+	for _, move := range moves {
+		if err := s.validate(move); err != nil {
+			return fmt.Errorf("move %d: %w", s.ply+1, err)
+		}
+		s.play(move)
+	}
+	return nil
+}
+
+func toMilliseconds(ds []time.Duration) []float64 {
+	if ds == nil {
+		return nil
+	}
+	ms := make([]float64, len(ds))
+	for i, d := range ds {
+		ms[i] = float64(d) / float64(time.Millisecond)
+	}
+	return ms
+}
+
+func fromMilliseconds(ms []float64) []time.Duration {
+	if ms == nil {
+		return nil
+	}
+	ds := make([]time.Duration, len(ms))
+	for i, m := range ms {
+		ds[i] = time.Duration(m * float64(time.Millisecond))
+	}
+	return ds
+}
+
+// WriteRecords writes records to w as JSON lines, one record per line.
+func WriteRecords(w io.Writer, records []*Record) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		err := encoder.Encode(record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadRecords reads records written by WriteRecords.
+func ReadRecords(r io.Reader) ([]*Record, error) {
+	var records []*Record
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		record := &Record{}
+		err := json.Unmarshal(scanner.Bytes(), record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
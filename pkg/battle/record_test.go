@@ -0,0 +1,78 @@
+package battle_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"testing"
+	"time"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestWriteRecords(t *testing.T) {
+	records := []*battle.Record{
+		{
+			Agents:       [2]string{"minimax:depth=2", "random"},
+			Players:      [2]int{1, 0},
+			Opening:      3,
+			Seed:         7,
+			Moves:        []ttt.Move{ttt.FromRowCol(4, 4), ttt.FromRowCol(3, 3), ttt.FromRowCol(0, 0)},
+			OpeningPlies: 1,
+			Times:        []time.Duration{1500 * time.Microsecond, 2 * time.Millisecond},
+			Limits:       []time.Duration{100 * time.Millisecond, 100 * time.Millisecond},
+			Winner:       0,
+			Termination:  battle.Illegal,
+		},
+		{
+			Agents:      [2]string{"random", "random"},
+			Winner:      -1,
+			Termination: battle.Draw,
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	err := battle.WriteRecords(buf, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := battle.ReadRecords(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(records, got, cmpopts.EquateEmpty()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRecord_Game(t *testing.T) {
+	record := &battle.Record{
+		Moves: []ttt.Move{ttt.FromRowCol(4, 4), ttt.FromRowCol(3, 3)},
+	}
+
+	game := record.Game(2)
+	if got := game.Boards[1][1].Cells[1][1]; got != ttt.Self {
+		t.Errorf("got %v in first move's cell, want Self", got)
+	}
+	if got := game.Boards[1][1].Cells[0][0]; got != ttt.Opponent {
+		t.Errorf("got %v in second move's cell, want Opponent", got)
+	}
+
+	game = record.Game(1)
+	if got := game.Boards[1][1].Cells[0][0]; got != ttt.None {
+		t.Errorf("got %v in second move's cell before it was played, want None", got)
+	}
+}
+
+func TestReadRecords_Illegal(t *testing.T) {
+	// The second move is in the wrong board, and the game did not end there.
+	input := `{"agents":["random","random"],"moves":"4 4 0 0 1 1","result":"1/2-1/2","termination":"draw"}`
+
+	_, err := battle.ReadRecords(bytes.NewBufferString(input))
+	if !errors.Is(err, ttt.ErrWrongBoard) {
+		t.Errorf("got error %v, want %v", err, ttt.ErrWrongBoard)
+	}
+}
@@ -0,0 +1,208 @@
+package battle
+
+import (
+	"encoding/json"
+	"math"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// Judgement classifies a move by how far its score falls short of the best
+// move's.
+type Judgement int
+
+const (
+	// Best means no move scored better.
+	Best Judgement = iota
+	Inaccuracy
+	Mistake
+	Blunder
+)
+
+var judgements = []string{"best", "inaccuracy", "mistake", "blunder"}
+
+func (j Judgement) String() string {
+	if int(j) < len(judgements) {
+		return judgements[j]
+	}
+	return "unknown"
+}
+
+func (j Judgement) MarshalText() ([]byte, error) {
+	return []byte(j.String()), nil
+}
+
+// Thresholds are the least drops in score from the best move, in evaluation
+// units, which make a move an inaccuracy, a mistake or a blunder. Winning a
+// board is worth about 100.
+type Thresholds struct {
+	Inaccuracy float64
+	Mistake    float64
+	Blunder    float64
+}
+
+var DefaultThresholds = Thresholds{
+	Inaccuracy: 10,
+	Mistake:    40,
+	Blunder:    100,
+}
+
+// judge classifies a move which scored score when the best move scored best.
+// Missing a forced win, or walking into a forced loss, is a blunder.
+func (t Thresholds) judge(score, best float64) Judgement {
+	if score >= best {
+		return Best
+	}
+	drop := best - score
+	switch {
+	case math.IsInf(drop, 1) || drop >= t.Blunder:
+		return Blunder
+	case drop >= t.Mistake:
+		return Mistake
+	case drop >= t.Inaccuracy:
+		return Inaccuracy
+	default:
+		return Best
+	}
+}
+
+// ReviewedMove is a move of a reviewed game.
+type ReviewedMove struct {
+	// Ply is the index of the move in the Moves of the Record.
+	Ply  int
+	Move ttt.Move
+
+	// Score is the score of Move for the player who made it, and BestScore the
+	// score of BestMove, the move the search prefers.
+	Score     float64
+	BestMove  ttt.Move
+	BestScore float64
+
+	// BestLine is the line the search expects after BestMove, starting with it.
+	BestLine []ttt.Move
+
+	Judgement Judgement
+}
+
+// Review is a game annotated by searching every position after its opening.
+type Review struct {
+	Record *Record
+	Depth  int
+
+	// Moves holds every move after the opening, except an illegal last move.
+	Moves []ReviewedMove
+
+	// Decided is the ply of the move from which the winner's score stayed at
+	// least the Blunder threshold to the end of the game, or -1 if the game was
+	// drawn or the winner never had such a lead.
+	Decided int
+}
+
+// ReviewGame searches the position before each move of record after the
+// opening to depth with s, and judges the move played against the best.
+func ReviewGame(s *ttt.Searcher, record *Record, depth int, thresholds Thresholds) *Review {
+	review := &Review{Record: record, Depth: depth, Decided: -1}
+
+	moves := record.Moves
+	if record.Termination == Illegal && len(moves) > 0 {
+		moves = moves[:len(moves)-1]
+	}
+
+	st := newState()
+	legal := make([]ttt.Move, 81)
+	for ply, move := range moves {
+		if ply >= record.OpeningPlies {
+			nLegal := st.legalMoves(legal)
+			analyses := s.Analyze(legal[:nLegal], st.games[st.toMove()], depth, 1)
+
+			reviewed := ReviewedMove{
+				Ply:       ply,
+				Move:      move,
+				BestMove:  analyses[0].Move,
+				BestScore: analyses[0].Score,
+				BestLine:  analyses[0].Line,
+			}
+			for _, analysis := range analyses {
+				if analysis.Move == move {
+					reviewed.Score = analysis.Score
+				}
+			}
+			reviewed.Judgement = thresholds.judge(reviewed.Score, reviewed.BestScore)
+			review.Moves = append(review.Moves, reviewed)
+		}
+		st.play(move)
+	}
+
+	if record.Winner != -1 {
+		for i := len(review.Moves) - 1; i >= 0; i-- {
+			m := review.Moves[i]
+			lead := m.Score
+			if record.mover(m.Ply) != record.Winner {
+				lead = -lead
+			}
+			if lead < thresholds.Blunder {
+				break
+			}
+			review.Decided = m.Ply
+		}
+	}
+
+	return review
+}
+
+// reviewJSON is the format Reviews are written in. Infinite scores, which JSON
+// numbers cannot hold, are written as the strings "+inf" and "-inf".
+type reviewJSON struct {
+	Agents  [2]string          `json:"agents"`
+	Opening int                `json:"opening"`
+	Seed    uint64             `json:"seed"`
+	Result  string             `json:"result"`
+	Depth   int                `json:"depth"`
+	Moves   []reviewedMoveJSON `json:"moves"`
+	Decided int                `json:"decided"`
+}
+
+type reviewedMoveJSON struct {
+	Ply       int       `json:"ply"`
+	Move      string    `json:"move"`
+	Score     any       `json:"score"`
+	BestMove  string    `json:"best_move"`
+	BestScore any       `json:"best_score"`
+	BestLine  string    `json:"best_line"`
+	Judgement Judgement `json:"judgement"`
+}
+
+func (r *Review) MarshalJSON() ([]byte, error) {
+	moves := make([]reviewedMoveJSON, len(r.Moves))
+	for i, m := range r.Moves {
+		moves[i] = reviewedMoveJSON{
+			Ply:       m.Ply,
+			Move:      m.Move.String(),
+			Score:     jsonScore(m.Score),
+			BestMove:  m.BestMove.String(),
+			BestScore: jsonScore(m.BestScore),
+			BestLine:  ttt.FormatMoves(m.BestLine),
+			Judgement: m.Judgement,
+		}
+	}
+
+	return json.Marshal(reviewJSON{
+		Agents:  r.Record.Agents,
+		Opening: r.Record.Opening,
+		Seed:    r.Record.Seed,
+		Result:  r.Record.Score(),
+		Depth:   r.Depth,
+		Moves:   moves,
+		Decided: r.Decided,
+	})
+}
+
+func jsonScore(score float64) any {
+	switch {
+	case math.IsInf(score, 1):
+		return "+inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	default:
+		return score
+	}
+}
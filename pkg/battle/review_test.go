@@ -0,0 +1,55 @@
+package battle_test
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestReviewGame(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	openings, err := battle.RandomOpenings(rng, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, records := battle.Battle(battle.NewMinimax(2), battle.NewRandom(1), openings, battle.TimeControl{})
+
+	thresholds := battle.DefaultThresholds
+	for i, record := range records {
+		review := battle.ReviewGame(ttt.NewSearcher(), record, 2, thresholds)
+		if got, want := len(review.Moves), len(record.Moves)-record.OpeningPlies; got != want {
+			t.Fatalf("%d: got %d reviewed moves, want %d", i, got, want)
+		}
+
+		for j, m := range review.Moves {
+			if m.Ply != record.OpeningPlies+j || m.Move != record.Moves[m.Ply] {
+				t.Errorf("%d: got move %v at ply %d, want %v at ply %d", i, m.Move, m.Ply, record.Moves[record.OpeningPlies+j], record.OpeningPlies+j)
+			}
+			if m.Score > m.BestScore {
+				t.Errorf("%d: ply %d scored %v, more than the best %v", i, m.Ply, m.Score, m.BestScore)
+			}
+			if len(m.BestLine) == 0 || m.BestLine[0] != m.BestMove {
+				t.Errorf("%d: ply %d: got line %v for best move %v", i, m.Ply, m.BestLine, m.BestMove)
+			}
+			drop := m.BestScore - m.Score
+			if m.Judgement == battle.Best && drop >= thresholds.Inaccuracy ||
+				m.Judgement == battle.Blunder && drop < thresholds.Blunder {
+				t.Errorf("%d: ply %d judged %s with a drop of %v", i, m.Ply, m.Judgement, drop)
+			}
+		}
+
+		if record.Winner != -1 && record.Termination == battle.Win {
+			// The winning move scores +Inf, so the game was decided by then.
+			if review.Decided < record.OpeningPlies || review.Decided >= len(record.Moves) {
+				t.Errorf("%d: got decided at ply %d of %d", i, review.Decided, len(record.Moves))
+			}
+		}
+
+		data, err := json.Marshal(review)
+		if err != nil || !json.Valid(data) {
+			t.Errorf("%d: got invalid JSON: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package battle
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// Sample is a position from self-play.
+type Sample struct {
+	// Cells holds the player in each cell of the 9x9 grid, counting along rows
+	// from the top left. The player which moved first is ttt.Self.
+	Cells [81]ttt.Player
+
+	// ToMove is the player whose turn it is.
+	ToMove ttt.Player
+
+	// Forced is the index of the board ToMove must play in, counting along rows
+	// from the top left, or -1 if they may play anywhere.
+	Forced int8
+
+	// Score is the value of the position from the search, for ToMove. Wins are
+	// scored WinScore.
+	Score float32
+
+	// Best is the move the search chose, which may not have been played.
+	Best ttt.Move
+
+	// Result is the eventual result of the game for ToMove: 1 for a win, 0 for
+	// a draw, and -1 for a loss.
+	Result int8
+}
+
+// WinScore is the Score of positions where the search found a win.
+const WinScore = 1e6
+
+func clampScore(score float64) float32 {
+	return float32(math.Max(math.Min(score, WinScore), -WinScore))
+}
+
+// sampleMagic begins binary sample files.
+const sampleMagic = "UTTTSP1\n"
+
+// sampleSize is the number of bytes a Sample takes in binary: two bits per
+// cell, then ToMove, Forced, Best and Result as one byte each, then Score as
+// a little-endian float32.
+const sampleSize = 21 + 4 + 4
+
+// SampleWriter writes samples in a compact binary format.
+type SampleWriter struct {
+	w       *bufio.Writer
+	started bool
+}
+
+func NewSampleWriter(w io.Writer) *SampleWriter {
+	return &SampleWriter{w: bufio.NewWriter(w)}
+}
+
+func (sw *SampleWriter) Write(sample *Sample) error {
+	if !sw.started {
+		_, err := sw.w.WriteString(sampleMagic)
+		if err != nil {
+			return err
+		}
+		sw.started = true
+	}
+
+	var buf [sampleSize]byte
+	for i, cell := range sample.Cells {
+		var bits byte
+		switch cell {
+		case ttt.Self:
+			bits = 1
+		case ttt.Opponent:
+			bits = 2
+		}
+		buf[i/4] |= bits << (2 * (i % 4))
+	}
+
+	buf[21] = byte(sample.ToMove)
+	buf[22] = byte(sample.Forced)
+	buf[23] = byte(sample.Best)
+	buf[24] = byte(sample.Result)
+	binary.LittleEndian.PutUint32(buf[25:], math.Float32bits(sample.Score))
+
+	_, err := sw.w.Write(buf[:])
+	return err
+}
+
+// Flush writes any buffered samples.
+func (sw *SampleWriter) Flush() error {
+	return sw.w.Flush()
+}
+
+// ReadSamples reads samples written by SampleWriter.
+func ReadSamples(r io.Reader) ([]Sample, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(sampleMagic))
+	_, err := io.ReadFull(br, magic)
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case string(magic) != sampleMagic:
+		return nil, errors.New("not a binary sample file")
+	}
+
+	var samples []Sample
+	var buf [sampleSize]byte
+	for {
+		_, err = io.ReadFull(br, buf[:])
+		if errors.Is(err, io.EOF) {
+			return samples, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("sample %d: %w", len(samples), err)
+		}
+
+		sample := Sample{
+			ToMove: ttt.Player(buf[21]),
+			Forced: int8(buf[22]),
+			Best:   ttt.Move(buf[23]),
+			Result: int8(buf[24]),
+			Score:  math.Float32frombits(binary.LittleEndian.Uint32(buf[25:])),
+		}
+		for i := range sample.Cells {
+			switch (buf[i/4] >> (2 * (i % 4))) & 0b11 {
+			case 1:
+				sample.Cells[i] = ttt.Self
+			case 2:
+				sample.Cells[i] = ttt.Opponent
+			}
+		}
+		samples = append(samples, sample)
+	}
+}
+
+// sampleJSON is the JSON lines format of a Sample, with cells as a string of
+// 'X', 'O' and '.' and the player which moved first as X.
+type sampleJSON struct {
+	Cells  string  `json:"cells"`
+	ToMove string  `json:"to_move"`
+	Forced int8    `json:"forced"`
+	Score  float32 `json:"score"`
+	Best   string  `json:"best"`
+	Result int8    `json:"result"`
+}
+
+func (s *Sample) MarshalJSON() ([]byte, error) {
+	cells := make([]byte, len(s.Cells))
+	for i, cell := range s.Cells {
+		cells[i] = ttt.Symbol(cell)
+	}
+
+	return json.Marshal(sampleJSON{
+		Cells:  string(cells),
+		ToMove: string(ttt.Symbol(s.ToMove)),
+		Forced: s.Forced,
+		Score:  s.Score,
+		Best:   s.Best.String(),
+		Result: s.Result,
+	})
+}
+
+func (s *Sample) UnmarshalJSON(data []byte) error {
+	var j sampleJSON
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+
+	if len(j.Cells) != len(s.Cells) {
+		return fmt.Errorf("got %d cells, want %d", len(j.Cells), len(s.Cells))
+	}
+	for i := range s.Cells {
+		s.Cells[i], err = parseSymbol(j.Cells[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(j.ToMove) != 1 {
+		return fmt.Errorf("invalid player to move %q", j.ToMove)
+	}
+	s.ToMove, err = parseSymbol(j.ToMove[0])
+	if err != nil {
+		return err
+	}
+
+	best, err := ttt.ParseMoves(j.Best)
+	if err != nil {
+		return err
+	}
+	if len(best) != 1 {
+		return fmt.Errorf("invalid best move %q", j.Best)
+	}
+	if j.Forced < ttt.Anywhere || j.Forced >= 9 {
+		return fmt.Errorf("invalid forced board %d", j.Forced)
+	}
+
+	s.Forced = j.Forced
+	s.Score = j.Score
+	s.Best = best[0]
+	s.Result = j.Result
+	return s.Game().Validate(s.Best)
+}
+
+func parseSymbol(c byte) (ttt.Player, error) {
+	switch c {
+	case 'X':
+		return ttt.Self, nil
+	case 'O':
+		return ttt.Opponent, nil
+	case '.':
+		return ttt.None, nil
+	default:
+		return ttt.None, fmt.Errorf("invalid cell %q", c)
+	}
+}
+
+// ReadSamplesJSON reads samples written as JSON lines.
+func ReadSamplesJSON(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var sample Sample
+		err := json.Unmarshal(scanner.Bytes(), &sample)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		samples = append(samples, sample)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+// LoadSamples reads samples from the file at path, in either the binary or the
+// JSON lines format.
+func LoadSamples(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(len(sampleMagic))
+
+	var samples []Sample
+	if string(magic) == sampleMagic {
+		samples, err = ReadSamples(br)
+	} else {
+		samples, err = ReadSamplesJSON(br)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading samples from %q: %w", path, err)
+	}
+	return samples, nil
+}
+
+// Game returns the position of s, with the player which moved first as
+// ttt.Self, and ToMove to move in the board Forced.
+func (s *Sample) Game() *ttt.Game {
+	game := ttt.NewGame()
+	for i, cell := range s.Cells {
+		if cell == ttt.None {
+			continue
+		}
+		row, col := uint8(i/9), uint8(i%9)
+		game.Boards[col/3][row/3].WithMove(col%3, row%3, cell)
+	}
+
+	// Mark won boards afterwards, since a board may have more than one line.
+	for a, col := range game.Boards {
+		for b, board := range col {
+			if winner := board.Winner(); winner != ttt.None {
+				game.Winners.WithMove(uint8(a), uint8(b), winner)
+			}
+		}
+	}
+	game.Rehash()
+	game.SetTurn(s.ToMove, int(s.Forced))
+	return game
+}
+
+// UniqueSamples returns the first of samples at each position, counting
+// positions which are the same up to symmetry as one.
+func UniqueSamples(samples []Sample) []Sample {
+	type key struct {
+		hash   uint64
+		toMove ttt.Player
+	}
+	seen := make(map[key]bool, len(samples))
+
+	var unique []Sample
+	for i := range samples {
+		hash, _ := samples[i].Game().CanonicalHash()
+		k := key{hash: hash, toMove: samples[i].ToMove}
+		if !seen[k] {
+			seen[k] = true
+			unique = append(unique, samples[i])
+		}
+	}
+	return unique
+}
+
+// Example returns s as a training example for a ttt.Network. The target mixes
+// the result of the game with the search score, squashed by tanh(score/scale),
+// giving the result weight lambda.
+func (s *Sample) Example(lambda, scale float64) ttt.Example {
+	active := make([]int, ttt.MaxActive)
+	n := ttt.Inputs(s.Game(), active)
+
+	target := lambda * float64(s.Result)
+	if lambda < 1 {
+		target += (1 - lambda) * math.Tanh(float64(s.Score)/scale)
+	}
+	return ttt.Example{Active: active[:n], Target: target}
+}
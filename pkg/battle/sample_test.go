@@ -0,0 +1,128 @@
+package battle_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func testSamples() []battle.Sample {
+	samples := []battle.Sample{
+		{
+			ToMove: ttt.Self,
+			Forced: -1,
+			Score:  battle.WinScore,
+			Best:   ttt.FromRowCol(4, 4),
+			Result: 1,
+		},
+		{
+			ToMove: ttt.Opponent,
+			Forced: 8,
+			Score:  -2.5,
+			Best:   ttt.FromRowCol(7, 7),
+			Result: -1,
+		},
+	}
+	samples[1].Cells[0] = ttt.Self
+	samples[1].Cells[40] = ttt.Opponent
+	samples[1].Cells[80] = ttt.Self
+	return samples
+}
+
+func TestSampleWriter(t *testing.T) {
+	samples := testSamples()
+
+	buf := &bytes.Buffer{}
+	sw := battle.NewSampleWriter(buf)
+	for i := range samples {
+		err := sw.Write(&samples[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := sw.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := buf.Len(), 8+29*len(samples); got != want {
+		t.Errorf("got %d bytes, want %d", got, want)
+	}
+
+	got, err := battle.ReadSamples(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(samples, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReadSamplesJSON(t *testing.T) {
+	samples := testSamples()
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for i := range samples {
+		err := encoder.Encode(&samples[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := battle.ReadSamplesJSON(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(samples, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSample_Game(t *testing.T) {
+	// X wins the top left board along its diagonal, and O holds the centre.
+	sample := battle.Sample{ToMove: ttt.Opponent, Forced: 4}
+	for _, i := range []int{0, 10, 20} {
+		sample.Cells[i] = ttt.Self
+	}
+	sample.Cells[40] = ttt.Opponent
+
+	want := ttt.NewGame()
+	for _, move := range []ttt.Move{ttt.FromRowCol(0, 0), ttt.FromRowCol(1, 1), ttt.FromRowCol(2, 2)} {
+		want.WithMove(move.XBoard(), move.YBoard(), move.XCell(), move.YCell(), ttt.Self)
+	}
+	want.WithMove(1, 1, 1, 1, ttt.Opponent)
+	want.SetTurn(ttt.Opponent, 4)
+
+	if diff := cmp.Diff(want, sample.Game(), cmp.AllowUnexported(ttt.Game{})); diff != "" {
+		t.Error(diff)
+	}
+
+	example := sample.Example(1, 100)
+	if example.Target != 0 {
+		t.Errorf("got target %v for a drawn game, want 0", example.Target)
+	}
+	if got, want := len(example.Active), 6; got != want {
+		t.Errorf("got %d active inputs, want %d", got, want)
+	}
+}
+
+func TestUniqueSamples(t *testing.T) {
+	// Corner moves are the same up to symmetry.
+	samples := make([]battle.Sample, 3)
+	samples[0].Cells[0] = ttt.Self
+	samples[1].Cells[80] = ttt.Self
+	samples[2].Cells[1] = ttt.Self
+	for i := range samples {
+		samples[i].ToMove = ttt.Opponent
+		samples[i].Forced = ttt.Anywhere
+	}
+
+	got := battle.UniqueSamples(samples)
+	if diff := cmp.Diff([]battle.Sample{samples[0], samples[2]}, got); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,176 @@
+package battle
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// SelfPlay plays the minimax search against itself to generate samples.
+type SelfPlay struct {
+	Depth   int
+	Weights ttt.Weights
+
+	// Network, if set, evaluates positions instead of Weights.
+	Network *ttt.Network
+
+	// Epsilon is the probability of playing a uniformly random move rather than
+	// the one chosen from the search.
+	Epsilon float64
+
+	// Temperature, if positive, chooses moves with probability proportional to
+	// exp(score/Temperature) rather than always the best.
+	Temperature float64
+
+	// RandomPlies is the number of plies at the start of each game which
+	// Epsilon and Temperature apply to, or zero for every ply.
+	RandomPlies int
+}
+
+// Game plays one game, returning a sample for every position where a move was
+// chosen.
+func (sp *SelfPlay) Game(rng *rand.Rand) []Sample {
+	var samples []Sample
+	var players []int
+
+	s := newState()
+	moves := make([]ttt.Move, 81)
+	scores := make([]float64, 81)
+	winner := -1
+	for {
+		nMoves := s.legalMoves(moves)
+		if nMoves == 0 || s.drawn() {
+			break
+		}
+
+		player := s.toMove()
+		searcher := ttt.NewSearcher()
+		searcher.Weights, searcher.Network = sp.Weights, sp.Network
+		searcher.ScoreMoves(moves[:nMoves], s.games[player], sp.Depth, scores)
+
+		best := 0
+		for i := range moves[:nMoves] {
+			if scores[i] > scores[best] {
+				best = i
+			}
+		}
+
+		samples = append(samples, sp.sample(s, moves[best], scores[best]))
+		players = append(players, player)
+
+		choice := best
+		if sp.RandomPlies == 0 || s.ply < sp.RandomPlies {
+			choice = sp.choose(rng, scores[:nMoves], best)
+		}
+
+		if s.play(moves[choice]) {
+			winner = player
+			break
+		}
+	}
+
+	for i := range samples {
+		switch winner {
+		case -1:
+		case players[i]:
+			samples[i].Result = 1
+		default:
+			samples[i].Result = -1
+		}
+	}
+
+	return samples
+}
+
+func (sp *SelfPlay) sample(s *state, best ttt.Move, score float64) Sample {
+	sample := Sample{
+		ToMove: ttt.Self,
+		Forced: int8(s.forced()),
+		Score:  clampScore(score),
+		Best:   best,
+	}
+	if s.toMove() == 1 {
+		sample.ToMove = ttt.Opponent
+	}
+
+	for a := range s.games[0].Boards {
+		for b, board := range s.games[0].Boards[a] {
+			for x := range board.Cells {
+				for y, cell := range board.Cells[x] {
+					sample.Cells[(b*3+y)*9+a*3+x] = cell
+				}
+			}
+		}
+	}
+
+	return sample
+}
+
+// choose returns the index of the move to play given the scores of each.
+func (sp *SelfPlay) choose(rng *rand.Rand, scores []float64, best int) int {
+	if math.IsInf(scores[best], 1) {
+		// Never throw away a win.
+		return best
+	}
+
+	if sp.Epsilon > 0 && rng.Float64() < sp.Epsilon {
+		return rng.IntN(len(scores))
+	}
+
+	return ttt.SampleScores(rng, scores, sp.Temperature)
+}
+
+// Run plays n games on workers goroutines, calling write with the samples of
+// each game as it finishes. Game i is seeded with seed+i. write is never called
+// concurrently. Stops at the first error write returns.
+func (sp *SelfPlay) Run(n, workers int, seed uint64, write func([]Sample) error) error {
+	games := make(chan int)
+	results := make(chan []Sample)
+	done := make(chan struct{})
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range games {
+				samples := sp.Game(rand.New(rand.NewPCG(seed+uint64(i), 0)))
+				select {
+				case results <- samples:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(games)
+		for i := 0; i < n; i++ {
+			select {
+			case games <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var err error
+	for samples := range results {
+		if err != nil {
+			continue
+		}
+		err = write(samples)
+		if err != nil {
+			close(done)
+		}
+	}
+
+	return err
+}
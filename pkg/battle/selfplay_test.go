@@ -0,0 +1,67 @@
+package battle_test
+
+import (
+	"math/rand/v2"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestSelfPlay_Game(t *testing.T) {
+	sp := &battle.SelfPlay{Depth: 1, Weights: ttt.DefaultWeights, Epsilon: 0.5}
+	samples := sp.Game(rand.New(rand.NewPCG(1, 0)))
+
+	if len(samples) == 0 {
+		t.Fatal("got no samples")
+	}
+
+	for i, sample := range samples {
+		pieces := 0
+		for _, cell := range sample.Cells {
+			if cell != ttt.None {
+				pieces++
+			}
+		}
+		if pieces != i {
+			t.Errorf("got %d pieces in sample %d, want %d", pieces, i, i)
+		}
+
+		wantToMove := ttt.Player(ttt.Self)
+		if i%2 == 1 {
+			wantToMove = ttt.Opponent
+		}
+		if sample.ToMove != wantToMove {
+			t.Errorf("got %v to move in sample %d, want %v", sample.ToMove, i, wantToMove)
+		}
+
+		// Results alternate in sign, since players alternate.
+		if i > 0 && sample.Result != -samples[i-1].Result {
+			t.Errorf("got result %d in sample %d after %d", sample.Result, i, samples[i-1].Result)
+		}
+	}
+
+	if samples[0].Forced != -1 {
+		t.Errorf("got forced board %d on the first move, want -1", samples[0].Forced)
+	}
+}
+
+func TestSelfPlay_Run(t *testing.T) {
+	sp := &battle.SelfPlay{Depth: 1, Weights: ttt.DefaultWeights, Temperature: 1}
+
+	count := func(workers int) int {
+		positions := 0
+		err := sp.Run(8, workers, 1, func(samples []battle.Sample) error {
+			positions += len(samples)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return positions
+	}
+
+	// Games are seeded by index, so the number of workers doesn't change them.
+	if serial, parallel := count(1), count(4); serial != parallel {
+		t.Errorf("got %d positions with 1 worker, %d with 4", serial, parallel)
+	}
+}
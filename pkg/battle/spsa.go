@@ -0,0 +1,232 @@
+package battle
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SPSAParam is an agent parameter tuned by SPSA.
+type SPSAParam struct {
+	Key   string  `json:"key"`
+	Value float64 `json:"value"`
+
+	// C is the size of the perturbation of the parameter in the first
+	// iteration, and the scale of its updates.
+	C float64 `json:"c"`
+
+	// Min and Max bound the value if they are not equal.
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+}
+
+// ParseSPSAParam parses a parameter of the form "key=value:c" or
+// "key=value:c:min:max", for example "meta=100:20".
+func ParseSPSAParam(s string) (SPSAParam, error) {
+	key, rest, ok := strings.Cut(s, "=")
+	fields := strings.Split(rest, ":")
+	if !ok || key == "" || (len(fields) != 2 && len(fields) != 4) {
+		return SPSAParam{}, fmt.Errorf("parameter %q is not of the form key=value:c[:min:max]", s)
+	}
+
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		var err error
+		values[i], err = strconv.ParseFloat(field, 64)
+		if err != nil {
+			return SPSAParam{}, fmt.Errorf("parameter %q: %w", s, err)
+		}
+	}
+
+	p := SPSAParam{Key: key, Value: values[0], C: values[1]}
+	if p.C <= 0 {
+		return SPSAParam{}, fmt.Errorf("parameter %q: c must be positive", s)
+	}
+	if len(values) == 4 {
+		p.Min, p.Max = values[2], values[3]
+		if p.Min >= p.Max {
+			return SPSAParam{}, fmt.Errorf("parameter %q: min must be less than max", s)
+		}
+	}
+
+	return p, nil
+}
+
+func (p *SPSAParam) clamp() {
+	if p.Min != p.Max {
+		p.Value = math.Min(math.Max(p.Value, p.Min), p.Max)
+	}
+}
+
+// SPSAStep records one iteration of tuning.
+type SPSAStep struct {
+	Iteration int       `json:"iteration"`
+	Plus      Result    `json:"plus"`
+	Values    []float64 `json:"values"`
+}
+
+// SPSA tunes agent parameters with simultaneous perturbation stochastic
+// approximation. Each iteration perturbs every parameter up or down at
+// random, battles the agent with the perturbation added against the agent
+// with it subtracted, and moves the parameters towards the better of the two.
+//
+// Parameters are tuned in units of their C, so in iteration k each is
+// perturbed by C/k^Gamma and moved by at most
+// C*LearningRate*k^Gamma/(2*(k+A)^Alpha).
+type SPSA struct {
+	// Spec is the agent to set the parameters on.
+	Spec   string      `json:"spec"`
+	Params []SPSAParam `json:"params"`
+
+	LearningRate float64 `json:"learning_rate"`
+	A            float64 `json:"a"`
+	Alpha        float64 `json:"alpha"`
+	Gamma        float64 `json:"gamma"`
+
+	// Pairs is the number of openings, each played twice, in an iteration, and
+	// Plies the number of random plies in each.
+	Pairs int `json:"pairs"`
+	Plies int `json:"plies"`
+
+	TimeControl string `json:"time_control,omitempty"`
+	Seed        uint64 `json:"seed"`
+
+	// Iteration is the number of iterations completed.
+	Iteration int        `json:"iteration"`
+	History   []SPSAStep `json:"history"`
+}
+
+// NewSPSA returns an SPSA with the usual exponents.
+func NewSPSA(spec string, params []SPSAParam) *SPSA {
+	return &SPSA{
+		Spec:         spec,
+		Params:       params,
+		LearningRate: 1.0,
+		A:            10,
+		Alpha:        0.602,
+		Gamma:        0.101,
+		Pairs:        8,
+		Plies:        4,
+		Seed:         1,
+	}
+}
+
+// agent creates the agent with every parameter offset by sign*perturbation.
+func (s *SPSA) agent(perturbation []float64, sign float64, seed uint64) (Agent, error) {
+	keys := make([]string, len(s.Params))
+	values := make([]string, len(s.Params))
+	for i, p := range s.Params {
+		p.Value += sign * perturbation[i]
+		p.clamp()
+		keys[i] = p.Key
+		values[i] = strconv.FormatFloat(p.Value, 'g', -1, 64)
+	}
+
+	spec, err := WithParams(s.Spec, keys, values)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAgent(spec, seed)
+}
+
+// Step runs one iteration of tuning.
+func (s *SPSA) Step() error {
+	tc, err := ParseTimeControl(s.TimeControl)
+	if err != nil {
+		return err
+	}
+
+	k := float64(s.Iteration + 1)
+	ck := math.Pow(k, -s.Gamma)
+	ak := s.LearningRate / math.Pow(k+s.A, s.Alpha)
+
+	// Seed each iteration from its index, so resumed runs match uninterrupted ones.
+	seed := s.Seed + uint64(s.Iteration)
+	rng := rand.New(rand.NewPCG(seed, 0))
+
+	delta := make([]float64, len(s.Params))
+	perturbation := make([]float64, len(s.Params))
+	for i, p := range s.Params {
+		delta[i] = float64(2*rng.IntN(2) - 1)
+		perturbation[i] = p.C * ck * delta[i]
+	}
+
+	plus, err := s.agent(perturbation, 1, seed)
+	if err != nil {
+		return err
+	}
+	minus, err := s.agent(perturbation, -1, seed+1)
+	if err != nil {
+		return err
+	}
+
+	openings, err := RandomOpenings(rng, s.Pairs, s.Plies)
+	if err != nil {
+		return err
+	}
+	result, _ := Battle(plus, minus, openings, tc)
+
+	// Plus scores result.Score() and minus the rest, so the estimated gradient
+	// in units of C is (2*score - 1) / (2*ck*delta).
+	gradient := (2*result.Score() - 1) / (2 * ck)
+	values := make([]float64, len(s.Params))
+	for i := range s.Params {
+		p := &s.Params[i]
+		p.Value += p.C * ak * gradient * delta[i]
+		p.clamp()
+		values[i] = p.Value
+	}
+
+	s.Iteration++
+	s.History = append(s.History, SPSAStep{Iteration: s.Iteration, Plus: result, Values: values})
+	return nil
+}
+
+// Save writes s to path, replacing the file only once it has been written in
+// full so an interrupted save leaves the previous checkpoint intact.
+func (s *SPSA) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSPSA reads a checkpoint written by Save.
+func LoadSPSA(path string) (*SPSA, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SPSA{}
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, fmt.Errorf("reading checkpoint %q: %w", path, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,91 @@
+package battle_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"path/filepath"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+)
+
+func TestParseSPSAParam(t *testing.T) {
+	tt := []struct {
+		input   string
+		want    battle.SPSAParam
+		wantErr bool
+	}{
+		{input: "meta=100:20", want: battle.SPSAParam{Key: "meta", Value: 100, C: 20}},
+		{input: "floor=-100:20:-200:0", want: battle.SPSAParam{Key: "floor", Value: -100, C: 20, Min: -200, Max: 0}},
+		{input: "meta=100", wantErr: true},
+		{input: "meta=100:0", wantErr: true},
+		{input: "meta=100:20:0", wantErr: true},
+		{input: "meta=100:20:200:0", wantErr: true},
+		{input: "meta=high:20", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := battle.ParseSPSAParam(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func newTestSPSA() *battle.SPSA {
+	s := battle.NewSPSA("minimax:depth=1", []battle.SPSAParam{
+		{Key: "meta", Value: 100, C: 20},
+		{Key: "boardwin", Value: 1, C: 0.5, Min: 0, Max: 2},
+	})
+	s.Pairs = 2
+	s.LearningRate = 10
+	return s
+}
+
+func TestSPSA_Resume(t *testing.T) {
+	uninterrupted := newTestSPSA()
+	for i := 0; i < 4; i++ {
+		err := uninterrupted.Step()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "spsa.json")
+	resumed := newTestSPSA()
+	for i := 0; i < 4; i++ {
+		err := resumed.Step()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = resumed.Save(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resumed, err = battle.LoadSPSA(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if diff := cmp.Diff(uninterrupted, resumed); diff != "" {
+		t.Error(diff)
+	}
+
+	for _, step := range resumed.History {
+		if got := step.Values[1]; got < 0 || got > 2 {
+			t.Errorf("got boardwin %v in iteration %d, want within [0, 2]", got, step.Iteration)
+		}
+	}
+}
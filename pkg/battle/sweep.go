@@ -0,0 +1,122 @@
+package battle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Axis is an agent parameter and the values to sweep it over.
+type Axis struct {
+	Key    string
+	Values []string
+}
+
+// ParseAxis parses an axis of the form "key=value,value,...", for example
+// "depth=2,3,4".
+func ParseAxis(s string) (Axis, error) {
+	key, values, ok := strings.Cut(s, "=")
+	if !ok || key == "" || values == "" {
+		return Axis{}, fmt.Errorf("axis %q is not of the form key=value,value,...", s)
+	}
+
+	return Axis{Key: key, Values: strings.Split(values, ",")}, nil
+}
+
+// Points returns every combination of values of axes. Each point holds one
+// value per axis, in the order of axes.
+func Points(axes []Axis) [][]string {
+	points := [][]string{nil}
+	for _, axis := range axes {
+		var next [][]string
+		for _, point := range points {
+			for _, value := range axis.Values {
+				next = append(next, append(point[:len(point):len(point)], value))
+			}
+		}
+		points = next
+	}
+	return points
+}
+
+// WithParams returns spec with each of keys set to the corresponding value,
+// replacing any value spec already has.
+func WithParams(spec string, keys, values []string) (string, error) {
+	name, params, err := parseSpec(spec)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the order parameters were written in.
+	_, rest, _ := strings.Cut(spec, ":")
+	var order []string
+	if rest != "" {
+		for _, param := range strings.Split(rest, ",") {
+			key, _, _ := strings.Cut(param, "=")
+			order = append(order, key)
+		}
+	}
+
+	for i, key := range keys {
+		if _, ok := params[key]; !ok {
+			order = append(order, key)
+		}
+		params[key] = values[i]
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(name)
+	for i, key := range order {
+		if i == 0 {
+			sb.WriteByte(':')
+		} else {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		sb.WriteString(params[key])
+	}
+	return sb.String(), nil
+}
+
+// SweepResult is the result of the agent at one point of a sweep against the
+// baseline.
+type SweepResult struct {
+	// Values holds the value of each axis.
+	Values []string
+	Agent  string
+	Result Result
+}
+
+// Sweep battles the agent specified by spec, with parameters set to each point
+// of axes, against baseline. Agents are created with seed.
+func Sweep(spec string, axes []Axis, seed uint64, baseline Agent, openings []Opening, tc TimeControl) ([]SweepResult, []*Record, error) {
+	keys := make([]string, len(axes))
+	for i, axis := range axes {
+		keys[i] = axis.Key
+	}
+
+	// Create every agent before playing so invalid points fail fast.
+	points := Points(axes)
+	agents := make([]Agent, len(points))
+	for i, point := range points {
+		pointSpec, err := WithParams(spec, keys, point)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		agents[i], err = ParseAgent(pointSpec, seed)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	results := make([]SweepResult, len(points))
+	var records []*Record
+	for i, agent := range agents {
+		result, pointRecords := Battle(agent, baseline, openings, tc)
+		results[i] = SweepResult{Values: points[i], Agent: agent.Name(), Result: result}
+		records = append(records, pointRecords...)
+	}
+
+	return results, records, nil
+}
@@ -0,0 +1,93 @@
+package battle_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+)
+
+func TestParseAxis(t *testing.T) {
+	tt := []struct {
+		input   string
+		want    battle.Axis
+		wantErr bool
+	}{
+		{input: "depth=2", want: battle.Axis{Key: "depth", Values: []string{"2"}}},
+		{input: "meta=50,100", want: battle.Axis{Key: "meta", Values: []string{"50", "100"}}},
+		{input: "depth", wantErr: true},
+		{input: "=2", wantErr: true},
+		{input: "depth=", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := battle.ParseAxis(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestPoints(t *testing.T) {
+	got := battle.Points([]battle.Axis{
+		{Key: "depth", Values: []string{"2", "3"}},
+		{Key: "meta", Values: []string{"50", "100", "200"}},
+	})
+
+	want := [][]string{
+		{"2", "50"}, {"2", "100"}, {"2", "200"},
+		{"3", "50"}, {"3", "100"}, {"3", "200"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestWithParams(t *testing.T) {
+	tt := []struct {
+		name   string
+		spec   string
+		keys   []string
+		values []string
+		want   string
+	}{
+		{
+			name:   "no parameters",
+			spec:   "minimax",
+			keys:   []string{"depth"},
+			values: []string{"3"},
+			want:   "minimax:depth=3",
+		},
+		{
+			name:   "replace parameter",
+			spec:   "minimax:depth=2,meta=50",
+			keys:   []string{"depth", "boardwin"},
+			values: []string{"3", "2"},
+			want:   "minimax:depth=3,meta=50,boardwin=2",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := battle.WithParams(tc.spec, tc.keys, tc.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package battle
+
+import (
+	"math"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// Position is a position from a finished game, labelled with the result.
+type Position struct {
+	// Features holds the value of each of ttt.Features from the perspective of
+	// the agent which moved first.
+	Features []float64
+
+	// Result is the points the agent which moved first scored in the game.
+	Result float64
+}
+
+// Positions extracts every position after the first move of each record.
+// Games which ended by timeout or illegal move are skipped, since their result
+// does not follow from the positions.
+func Positions(records []*Record) []Position {
+	var positions []Position
+	for _, record := range records {
+		if record.Termination == Timeout || record.Termination == Illegal {
+			continue
+		}
+
+		result := record.Result(0).Points()
+		s := newState()
+		for _, move := range record.Moves {
+			s.play(move)
+
+			features := make([]float64, len(ttt.Features))
+			for i, feature := range ttt.Features {
+				features[i] = feature.Value(s.games[0])
+			}
+			positions = append(positions, Position{Features: features, Result: result})
+		}
+	}
+	return positions
+}
+
+// Texel fits the weights of ttt.Features to positions by gradient descent on
+// the logistic loss between the evaluation and the result, after choosing the
+// scale K which converts evaluations to expected scores for the starting
+// weights. Weights of anything other than features are left unchanged.
+type Texel struct {
+	Weights ttt.Weights
+
+	// K is the scale fit by FitK, so that an evaluation e predicts a score of
+	// 1/(1+exp(-K*e)).
+	K float64
+
+	LearningRate float64
+}
+
+func NewTexel(weights ttt.Weights) *Texel {
+	return &Texel{Weights: weights, K: 1, LearningRate: 0.5}
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+func (t *Texel) evaluate(p *Position) float64 {
+	e := 0.0
+	for i, feature := range ttt.Features {
+		e += *feature.Weight(&t.Weights) * p.Features[i]
+	}
+	return e
+}
+
+// Loss returns the mean logistic loss of the predicted scores of positions.
+func (t *Texel) Loss(positions []Position) float64 {
+	loss := 0.0
+	for i := range positions {
+		p := sigmoid(t.K * t.evaluate(&positions[i]))
+		p = math.Min(math.Max(p, 1e-12), 1-1e-12)
+		r := positions[i].Result
+		loss -= r*math.Log(p) + (1-r)*math.Log(1-p)
+	}
+	return loss / float64(len(positions))
+}
+
+// FitK chooses the K which minimizes the loss for the current weights.
+func (t *Texel) FitK(positions []Position) {
+	// The loss is convex in K, so ternary search in log space.
+	lo, hi := -12.0, 4.0
+	for i := 0; i < 100; i++ {
+		m1 := lo + (hi-lo)/3
+		m2 := hi - (hi-lo)/3
+
+		t.K = math.Exp(m1)
+		l1 := t.Loss(positions)
+		t.K = math.Exp(m2)
+		l2 := t.Loss(positions)
+
+		if l1 < l2 {
+			hi = m2
+		} else {
+			lo = m1
+		}
+	}
+	t.K = math.Exp((lo + hi) / 2)
+}
+
+// Step takes one step of gradient descent on the weights of features.
+// Returns the loss before the step.
+func (t *Texel) Step(positions []Position) float64 {
+	n := len(ttt.Features)
+	gradient := make([]float64, n)
+	scale := make([]float64, n)
+	loss := 0.0
+
+	for i := range positions {
+		position := &positions[i]
+		p := sigmoid(t.K * t.evaluate(position))
+		r := position.Result
+
+		pc := math.Min(math.Max(p, 1e-12), 1-1e-12)
+		loss -= r*math.Log(pc) + (1-r)*math.Log(1-pc)
+
+		for j, f := range position.Features {
+			gradient[j] += (p - r) * t.K * f
+			scale[j] += t.K * t.K * f * f
+		}
+	}
+
+	// Scale each step by the curvature of the loss in that weight, so features
+	// with large values don't dominate.
+	for j, feature := range ttt.Features {
+		if scale[j] == 0 {
+			continue
+		}
+		*feature.Weight(&t.Weights) -= t.LearningRate * 4 * gradient[j] / scale[j]
+	}
+
+	return loss / float64(len(positions))
+}
@@ -0,0 +1,75 @@
+package battle_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestPositions(t *testing.T) {
+	records := []*battle.Record{
+		{
+			Moves:       []ttt.Move{ttt.FromRowCol(4, 4), ttt.FromRowCol(3, 3)},
+			Winner:      1,
+			Termination: battle.Win,
+		},
+		{
+			Moves:       []ttt.Move{ttt.FromRowCol(4, 4)},
+			Winner:      0,
+			Termination: battle.Timeout,
+		},
+	}
+
+	got := battle.Positions(records)
+	if len(got) != 2 {
+		t.Fatalf("got %d positions, want 2", len(got))
+	}
+	for _, position := range got {
+		if position.Result != 0 {
+			t.Errorf("got result %v, want 0", position.Result)
+		}
+		if len(position.Features) != len(ttt.Features) {
+			t.Errorf("got %d features, want %d", len(position.Features), len(ttt.Features))
+		}
+	}
+}
+
+func TestTexel(t *testing.T) {
+	// Positions where the first feature predicts the result and the rest are
+	// noise.
+	rng := rand.New(rand.NewPCG(1, 0))
+	positions := make([]battle.Position, 2000)
+	for i := range positions {
+		features := make([]float64, len(ttt.Features))
+		for j := range features {
+			features[j] = rng.NormFloat64()
+		}
+
+		result := 0.0
+		if rng.Float64() < 1/(1+math.Exp(-2*features[0])) {
+			result = 1.0
+		}
+		positions[i] = battle.Position{Features: features, Result: result}
+	}
+
+	texel := battle.NewTexel(ttt.Weights{Meta: 0.1, Board: 0.1})
+	texel.FitK(positions)
+
+	before := texel.Loss(positions)
+	for i := 0; i < 200; i++ {
+		texel.Step(positions)
+	}
+	after := texel.Loss(positions)
+
+	if after >= before {
+		t.Errorf("got loss %v after fitting, want less than %v", after, before)
+	}
+	if texel.Weights.Meta <= 0 {
+		t.Errorf("got meta weight %v, want positive", texel.Weights.Meta)
+	}
+	if texel.Weights.Meta < 5*math.Abs(texel.Weights.Board) {
+		t.Errorf("got meta weight %v and noise weight %v, want meta to dominate", texel.Weights.Meta, texel.Weights.Board)
+	}
+}
@@ -0,0 +1,62 @@
+package battle
+
+// Pairing is a match between the agents at two indices of a tournament.
+type Pairing [2]int
+
+// RoundRobin pairs every one of n agents against every other.
+func RoundRobin(n int) []Pairing {
+	var pairings []Pairing
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			pairings = append(pairings, Pairing{i, j})
+		}
+	}
+	return pairings
+}
+
+// Gauntlet pairs the first of n agents against each of the others.
+func Gauntlet(n int) []Pairing {
+	var pairings []Pairing
+	for j := 1; j < n; j++ {
+		pairings = append(pairings, Pairing{0, j})
+	}
+	return pairings
+}
+
+// Crosstable holds the results of a tournament. Crosstable[i][j] is the result
+// of agent i against agent j.
+type Crosstable [][]Result
+
+// Tournament battles the agents in each pairing over every opening. Returns the
+// crosstable of results and the record of every game.
+func Tournament(agents []Agent, pairings []Pairing, openings []Opening, tc TimeControl) (Crosstable, []*Record) {
+	table := make(Crosstable, len(agents))
+	for i := range table {
+		table[i] = make([]Result, len(agents))
+	}
+
+	var records []*Record
+	for _, pairing := range pairings {
+		i, j := pairing[0], pairing[1]
+		result, pairingRecords := Battle(agents[i], agents[j], openings, tc)
+		table[i][j] = table[i][j].Add(result)
+		table[j][i] = table[j][i].Add(result.Reverse())
+		for _, record := range pairingRecords {
+			for k, player := range record.Players {
+				record.Players[k] = pairing[player]
+			}
+		}
+		records = append(records, pairingRecords...)
+	}
+
+	return table, records
+}
+
+// Total returns the combined result of agent i against all opponents.
+func (t Crosstable) Total(i int) Result {
+	total := Result{}
+	for _, result := range t[i] {
+		total = total.Add(result)
+	}
+	return total
+}
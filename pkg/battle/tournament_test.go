@@ -0,0 +1,67 @@
+package battle_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+)
+
+func TestRoundRobin(t *testing.T) {
+	got := battle.RoundRobin(4)
+	want := []battle.Pairing{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestGauntlet(t *testing.T) {
+	got := battle.Gauntlet(4)
+	want := []battle.Pairing{{0, 1}, {0, 2}, {0, 3}}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestTournament(t *testing.T) {
+	agents := []battle.Agent{battle.NewRandom(1), battle.NewRandom(2), battle.NewMinimax(1)}
+	openings, err := battle.RandomOpenings(rand.New(rand.NewPCG(1, 0)), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table, records := battle.Tournament(agents, battle.RoundRobin(len(agents)), openings, battle.TimeControl{})
+	if len(records) != 18 {
+		t.Errorf("got %d records, want 18", len(records))
+	}
+
+	for i := range table {
+		for j := range table[i] {
+			if table[i][j] != table[j][i].Reverse() {
+				t.Errorf("got %v for %d against %d, but %v for %d against %d",
+					table[i][j], i, j, table[j][i], j, i)
+			}
+		}
+
+		if got := table.Total(i).Games(); got != 12 {
+			t.Errorf("got %d games for agent %d, want 12", got, i)
+		}
+	}
+
+	// The two random agents share a name, so records tell them apart by index.
+	games := make([]int, len(agents))
+	for _, record := range records {
+		for k, player := range record.Players {
+			if record.Agents[k] != agents[player].Name() {
+				t.Errorf("got agent %q as player %d, want %q", record.Agents[k], player, agents[player].Name())
+			}
+			games[player]++
+		}
+	}
+	if want := []int{12, 12, 12}; !slices.Equal(games, want) {
+		t.Errorf("got %v games by player index, want %v", games, want)
+	}
+}
@@ -0,0 +1,210 @@
+package battle
+
+import (
+	"math/rand/v2"
+	"sync"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// Zero trains a ttt.PolicyNetwork as AlphaZero does, alternating between
+// generating positions by PUCT self-play with the current network and training
+// the network on them.
+type Zero struct {
+	Network *ttt.PolicyNetwork
+	Trainer *ttt.PolicyTrainer
+
+	// Games is the number of self-play games per iteration, played on Workers
+	// goroutines.
+	Games   int
+	Workers int
+
+	Simulations int
+	CPuct       float64
+
+	// NoiseAlpha and NoiseFraction set the Dirichlet noise at the root of
+	// every self-play search.
+	NoiseAlpha    float64
+	NoiseFraction float64
+
+	// TemperaturePlies is the number of plies at the start of each game where
+	// moves are sampled in proportion to their visits, rather than the most
+	// visited being played.
+	TemperaturePlies int
+
+	// Epochs is the number of passes over the positions per iteration.
+	Epochs int
+
+	// Window is the number of most recent iterations whose positions are
+	// trained on.
+	Window int
+
+	Seed      uint64
+	Iteration int
+
+	positions [][]ttt.PolicyExample
+}
+
+// NewZero returns a Zero training network with the settings of AlphaZero,
+// scaled down for the CPU.
+func NewZero(network *ttt.PolicyNetwork) *Zero {
+	return &Zero{
+		Network:          network,
+		Trainer:          ttt.NewPolicyTrainer(network),
+		Games:            100,
+		Workers:          1,
+		Simulations:      ttt.DefaultSimulations,
+		CPuct:            ttt.DefaultCPuct,
+		NoiseAlpha:       0.3,
+		NoiseFraction:    0.25,
+		TemperaturePlies: 10,
+		Epochs:           2,
+		Window:           4,
+		Seed:             1,
+	}
+}
+
+// ZeroStep summarizes one iteration of Zero.
+type ZeroStep struct {
+	Iteration int
+
+	// Result is the result of the games for the player which moved first.
+	Result    Result
+	Positions int
+
+	// ValueLoss and PolicyLoss are the mean losses over the last epoch.
+	ValueLoss  float64
+	PolicyLoss float64
+}
+
+// Step plays a round of self-play games and trains the network on the
+// positions from the last Window rounds.
+func (z *Zero) Step() ZeroStep {
+	step := ZeroStep{Iteration: z.Iteration}
+
+	games := make([][]ttt.PolicyExample, z.Games)
+	winners := make([]int, z.Games)
+	next := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < z.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				seed := z.Seed + uint64(z.Iteration*z.Games+i)
+				games[i], winners[i] = z.Game(rand.New(rand.NewPCG(seed, 0)))
+			}
+		}()
+	}
+	for i := range games {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var positions []ttt.PolicyExample
+	for i, game := range games {
+		positions = append(positions, game...)
+		switch winners[i] {
+		case -1:
+			step.Result.Draws++
+		case 0:
+			step.Result.Wins++
+		default:
+			step.Result.Losses++
+		}
+	}
+	step.Positions = len(positions)
+
+	z.positions = append(z.positions, positions)
+	if len(z.positions) > z.Window {
+		z.positions = z.positions[len(z.positions)-z.Window:]
+	}
+	var window []ttt.PolicyExample
+	for _, p := range z.positions {
+		window = append(window, p...)
+	}
+
+	rng := rand.New(rand.NewPCG(z.Seed+uint64(z.Iteration), 1))
+	for epoch := 0; epoch < z.Epochs; epoch++ {
+		step.ValueLoss, step.PolicyLoss = z.Trainer.Epoch(window, rng)
+	}
+
+	z.Iteration++
+	return step
+}
+
+// Game plays one game of PUCT self-play, returning an example for every
+// position with the visit distribution of the search as the policy, and the
+// index of the winner in move order, or -1 for a draw.
+func (z *Zero) Game(rng *rand.Rand) ([]ttt.PolicyExample, int) {
+	var examples []ttt.PolicyExample
+	var players []int
+
+	s := newState()
+	moves := make([]ttt.Move, 81)
+	visits := make([]int, 81)
+	winner := -1
+	for {
+		nMoves := s.legalMoves(moves)
+		if nMoves == 0 || s.drawn() {
+			break
+		}
+		legal := moves[:nMoves]
+
+		player := s.toMove()
+		search := ttt.NewPUCT(z.Network, rng)
+		search.Simulations = z.Simulations
+		search.CPuct = z.CPuct
+		search.NoiseAlpha = z.NoiseAlpha
+		search.NoiseFraction = z.NoiseFraction
+		search.Search(legal, s.games[player], visits)
+
+		examples = append(examples, policyExample(s.games[player], legal, visits[:nMoves]))
+		players = append(players, player)
+
+		temperature := 0.0
+		if s.ply < z.TemperaturePlies {
+			temperature = 1
+		}
+		choice := legal[ttt.SampleVisits(rng, visits[:nMoves], temperature)]
+
+		if s.play(choice) {
+			winner = player
+			break
+		}
+	}
+
+	for i := range examples {
+		switch winner {
+		case -1:
+		case players[i]:
+			examples[i].Value = 1
+		default:
+			examples[i].Value = -1
+		}
+	}
+
+	return examples, winner
+}
+
+// policyExample returns a training example for game, where Self is to move, with the
+// visit distribution over moves as the policy.
+func policyExample(game *ttt.Game, moves []ttt.Move, visits []int) ttt.PolicyExample {
+	active := make([]int, ttt.MaxActive)
+	nActive := ttt.Inputs(game, active)
+
+	total := 0
+	for _, n := range visits {
+		total += n
+	}
+	policy := make([]float64, len(moves))
+	for i, n := range visits {
+		policy[i] = float64(n) / float64(total)
+	}
+
+	return ttt.PolicyExample{
+		Active: active[:nActive],
+		Moves:  append([]ttt.Move(nil), moves...),
+		Policy: policy,
+	}
+}
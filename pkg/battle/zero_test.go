@@ -0,0 +1,60 @@
+package battle_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/battle"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestZero_Step(t *testing.T) {
+	network := ttt.NewPolicyNetwork([]int{8}, rand.New(rand.NewPCG(1, 0)))
+	zero := battle.NewZero(network)
+	zero.Games = 2
+	zero.Workers = 2
+	zero.Simulations = 10
+
+	step := zero.Step()
+	if step.Result.Games() != zero.Games {
+		t.Errorf("got %d games, want %d", step.Result.Games(), zero.Games)
+	}
+	if step.Positions == 0 {
+		t.Error("got no positions")
+	}
+	if math.IsNaN(step.ValueLoss) || math.IsNaN(step.PolicyLoss) {
+		t.Errorf("got losses %v and %v", step.ValueLoss, step.PolicyLoss)
+	}
+	if zero.Iteration != 1 {
+		t.Errorf("got iteration %d, want 1", zero.Iteration)
+	}
+}
+
+func TestZero_Game(t *testing.T) {
+	network := ttt.NewPolicyNetwork([]int{8}, rand.New(rand.NewPCG(1, 0)))
+	zero := battle.NewZero(network)
+	zero.Simulations = 10
+
+	examples, winner := zero.Game(rand.New(rand.NewPCG(2, 0)))
+	for i, example := range examples {
+		sum := 0.0
+		for _, p := range example.Policy {
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("example %d: got policy summing to %v, want 1", i, sum)
+		}
+
+		// Values alternate with the player to move.
+		want := 0.0
+		if winner != -1 {
+			want = 1
+			if i%2 != winner {
+				want = -1
+			}
+		}
+		if example.Value != want {
+			t.Errorf("example %d: got value %v, want %v", i, example.Value, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package ttt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+// Book maps positions to the move to play. It is stored compactly so that it
+// can be embedded in a single submission file as a string: each entry is the
+// top 40 bits of the position's Hash, big-endian, followed by the Move, with
+// entries sorted by hash.
+type Book struct {
+	data string
+}
+
+const (
+	bookEntrySize = 6
+	bookHashShift = 24
+)
+
+// NewBook returns the book stored in data.
+func NewBook(data string) (*Book, error) {
+	if len(data)%bookEntrySize != 0 {
+		return nil, fmt.Errorf("book of %d bytes is not a whole number of %d byte entries", len(data), bookEntrySize)
+	}
+
+	b := &Book{data: data}
+	for i := 1; i < b.Len(); i++ {
+		if b.key(i-1) >= b.key(i) {
+			return nil, errors.New("book entries are not sorted by hash")
+		}
+	}
+	return b, nil
+}
+
+// BuildBook returns a book of entries, keyed by Hash.
+func BuildBook(entries map[uint64]Move) *Book {
+	keys := make([]uint64, 0, len(entries))
+	moves := make(map[uint64]Move, len(entries))
+	for hash, move := range entries {
+		key := hash >> bookHashShift
+		if _, ok := moves[key]; !ok {
+			keys = append(keys, key)
+		}
+		moves[key] = move
+	}
+	slices.Sort(keys)
+
+	data := make([]byte, 0, len(keys)*bookEntrySize)
+	for _, key := range keys {
+		data = append(data, byte(key>>32), byte(key>>24), byte(key>>16), byte(key>>8), byte(key), byte(moves[key]))
+	}
+	return &Book{data: string(data)}
+}
+
+// Data returns the stored form of the book, as read by NewBook.
+func (b *Book) Data() string {
+	return b.data
+}
+
+// Len returns the number of positions in the book.
+func (b *Book) Len() int {
+	return len(b.data) / bookEntrySize
+}
+
+// Entries returns the positions in the book. Only the bits of each hash which
+// the book stores are set.
+func (b *Book) Entries() map[uint64]Move {
+	entries := make(map[uint64]Move, b.Len())
+	for i := 0; i < b.Len(); i++ {
+		entries[b.key(i)<<bookHashShift] = b.move(i)
+	}
+	return entries
+}
+
+// Lookup returns the move for the position with hash, if it is in the book.
+func (b *Book) Lookup(hash uint64) (Move, bool) {
+	key := hash >> bookHashShift
+	i := sort.Search(b.Len(), func(i int) bool {
+		return b.key(i) >= key
+	})
+	if i == b.Len() || b.key(i) != key {
+		return 0, false
+	}
+	return b.move(i), true
+}
+
+// LookupSymmetric returns the move for game, if the book has it or a position
+// symmetric to it. Books which store only the canonical form of each position
+// are read this way.
+func (b *Book) LookupSymmetric(game *Game) (Move, bool) {
+	for s := Identity; s < Symmetries; s++ {
+		if move, ok := b.Lookup(game.SymmetricHash(s)); ok {
+			return s.Inverse().Move(move), true
+		}
+	}
+	return 0, false
+}
+
+func (b *Book) key(i int) uint64 {
+	entry := b.data[i*bookEntrySize:]
+	return uint64(entry[0])<<32 | uint64(entry[1])<<24 | uint64(entry[2])<<16 | uint64(entry[3])<<8 | uint64(entry[4])
+}
+
+func (b *Book) move(i int) Move {
+	return Move(b.data[i*bookEntrySize+5])
+}
+
+// LoadBook reads a book from the file at path.
+func LoadBook(path string) (*Book, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := NewBook(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("reading book from %q: %w", path, err)
+	}
+	return b, nil
+}
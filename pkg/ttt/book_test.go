@@ -0,0 +1,96 @@
+package ttt_test
+
+import (
+	"math/rand/v2"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestBook(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	entries := make(map[uint64]ttt.Move)
+	for i := 0; i < 100; i++ {
+		entries[rng.Uint64()] = ttt.Move(rng.IntN(256))
+	}
+
+	book, err := ttt.NewBook(ttt.BuildBook(entries).Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Len() != len(entries) {
+		t.Errorf("got %d entries, want %d", book.Len(), len(entries))
+	}
+
+	for hash, want := range entries {
+		got, ok := book.Lookup(hash)
+		if !ok || got != want {
+			t.Errorf("Lookup(%x) = %v, %t, want %v, true", hash, got, ok, want)
+		}
+	}
+
+	if _, ok := book.Lookup(rng.Uint64()); ok {
+		t.Error("found a position not in the book")
+	}
+
+	// Entries round trips through BuildBook.
+	if got := ttt.BuildBook(book.Entries()).Data(); got != book.Data() {
+		t.Error("rebuilding the book from its entries changed it")
+	}
+
+	if _, err := ttt.NewBook("12345"); err == nil {
+		t.Error("got no error for a partial entry")
+	}
+	data := book.Data()
+	if _, err := ttt.NewBook(data[6:12] + data[:6]); err == nil {
+		t.Error("got no error for unsorted entries")
+	}
+}
+
+func TestGame_Hash(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	game := ttt.NewGame()
+	empty := game.Hash()
+	game.SetTurn(ttt.Self, 4)
+	if game.Hash() == empty {
+		t.Error("got the same hash for different forced boards")
+	}
+	game.SetTurn(ttt.Self, ttt.Anywhere)
+
+	moves := make([]ttt.Move, 81)
+	type played struct {
+		move      ttt.Move
+		player    ttt.Player
+		winsBoard bool
+	}
+	var history []played
+	var player ttt.Player = ttt.Self
+	last := ttt.ToMove(0, 0, 1, 1)
+	for ply := 0; ply < 30; ply++ {
+		n := game.LegalMoves(last.XCell(), last.YCell(), moves)
+		if n == 0 {
+			break
+		}
+		move := moves[rng.IntN(n)]
+		isWin, winsBoard := game.WithMove(move.XBoard(), move.YBoard(), move.XCell(), move.YCell(), player)
+		history = append(history, played{move, player, winsBoard})
+		if isWin {
+			break
+		}
+		last, player = move, -player
+	}
+
+	// The incremental hash matches one computed from scratch.
+	want := game.Hash()
+	game.Rehash()
+	if got := game.Hash(); got != want {
+		t.Errorf("got %x after Rehash, want incremental %x", got, want)
+	}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		p := history[i]
+		game.WithoutMove(p.move.XBoard(), p.move.YBoard(), p.move.XCell(), p.move.YCell(), p.player, p.winsBoard)
+	}
+	if got := game.Hash(); got != empty {
+		t.Errorf("got %x after undoing every move, want %x", got, empty)
+	}
+}
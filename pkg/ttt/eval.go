@@ -0,0 +1,186 @@
+package ttt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+)
+
+// Weights are the parameters of the evaluation used by Searcher.
+type Weights struct {
+	// Meta multiplies the score of the meta-board of won boards.
+	Meta float64 `json:"meta"`
+
+	// Board multiplies the score of each small board.
+	Board float64 `json:"board"`
+
+	// BoardWin is added for each small board won along a searched line, and
+	// subtracted for each won by the opponent.
+	BoardWin float64 `json:"boardwin"`
+
+	// Floor is the lowest value Self's moves are given before any is searched.
+	Floor float64 `json:"floor"`
+
+	// SendFree, SendBoard and SendGame score the board the player to move at
+	// the end of a searched line was sent to: if they may play anywhere, can
+	// win a board, or can win the game. They are subtracted when Opponent is
+	// to move and added when Self is.
+	SendFree  float64 `json:"sendfree"`
+	SendBoard float64 `json:"sendboard"`
+	SendGame  float64 `json:"sendgame"`
+}
+
+var DefaultWeights = Weights{
+	Meta:      100,
+	Board:     1,
+	BoardWin:  1,
+	Floor:     -100,
+	SendFree:  2,
+	SendBoard: 10,
+	SendGame:  100,
+}
+
+// Evaluate scores game from the perspective of Self. With EvaluateSend, it is
+// the sum of each of Features multiplied by its weight.
+func (w *Weights) Evaluate(game *Game) float64 {
+	score := w.Meta * float64(metaValue(game))
+	return score + w.Board*float64(boardsValue(game))
+}
+
+// EvaluateSend scores the board the player to move was sent to, from the
+// perspective of Self.
+func (w *Weights) EvaluateSend(game *Game) float64 {
+	send := game.Send()
+	value := 0.0
+	if send.Free {
+		value += w.SendFree
+	}
+	if send.WinsBoard {
+		value += w.SendBoard
+	}
+	if send.WinsGame {
+		value += w.SendGame
+	}
+	return float64(game.ToMove()) * value
+}
+
+// metaValue returns the number of boards each player has won in lines of
+// boards they can still complete, Self's counted positive and Opponent's
+// negative.
+func metaValue(game *Game) int {
+	value := 0
+	for _, player := range []Player{Self, Opponent} {
+		won, winnable := game.won(player), game.open[side(player)]
+		for _, line := range lineMasks {
+			if winnable&line == line {
+				value += int(player) * bits.OnesCount16(won&line)
+			}
+		}
+	}
+	return value
+}
+
+// boardsValue returns the sum of the static values of the small boards which
+// are not yet won.
+func boardsValue(game *Game) int {
+	value := 0
+	for a, row := range game.Boards {
+		for b, board := range row {
+			if !game.Winners.Taken[a][b] {
+				value += int(board.Info().Value)
+			}
+		}
+	}
+	return value
+}
+
+// Feature is a term of Evaluate.
+type Feature struct {
+	Name string
+
+	// Weight returns the weight which multiplies the feature.
+	Weight func(w *Weights) *float64
+
+	// Value computes the feature from the perspective of Self.
+	Value func(game *Game) float64
+}
+
+// Features are the terms Evaluate and EvaluateSend sum, for tuning their
+// weights.
+var Features = []Feature{
+	{
+		Name:   "meta",
+		Weight: func(w *Weights) *float64 { return &w.Meta },
+		Value: func(game *Game) float64 {
+			return float64(metaValue(game))
+		},
+	},
+	{
+		Name:   "board",
+		Weight: func(w *Weights) *float64 { return &w.Board },
+		Value: func(game *Game) float64 {
+			return float64(boardsValue(game))
+		},
+	},
+	{
+		Name:   "sendfree",
+		Weight: func(w *Weights) *float64 { return &w.SendFree },
+		Value: func(game *Game) float64 {
+			return sendValue(game, game.Send().Free)
+		},
+	},
+	{
+		Name:   "sendboard",
+		Weight: func(w *Weights) *float64 { return &w.SendBoard },
+		Value: func(game *Game) float64 {
+			return sendValue(game, game.Send().WinsBoard)
+		},
+	},
+	{
+		Name:   "sendgame",
+		Weight: func(w *Weights) *float64 { return &w.SendGame },
+		Value: func(game *Game) float64 {
+			return sendValue(game, game.Send().WinsGame)
+		},
+	},
+}
+
+// sendValue returns the value of a term of EvaluateSend which applies if
+// applies: 1 if Self is to move, and -1 if Opponent is.
+func sendValue(game *Game, applies bool) float64 {
+	if !applies {
+		return 0
+	}
+	return float64(game.ToMove())
+}
+
+// ReadWeights reads weights written by WriteWeights. Weights missing from r
+// keep their default value.
+func ReadWeights(r io.Reader) (Weights, error) {
+	w := DefaultWeights
+	err := json.NewDecoder(r).Decode(&w)
+	return w, err
+}
+
+// LoadWeights reads weights from the file at path.
+func LoadWeights(path string) (Weights, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Weights{}, err
+	}
+	defer f.Close()
+
+	w, err := ReadWeights(f)
+	if err != nil {
+		return Weights{}, fmt.Errorf("reading weights from %q: %w", path, err)
+	}
+	return w, nil
+}
+
+func WriteWeights(w io.Writer, weights Weights) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(weights)
+}
@@ -0,0 +1,61 @@
+package ttt_test
+
+import (
+	"bytes"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestWeights_Evaluate(t *testing.T) {
+	weights := ttt.Weights{Meta: 3, Board: 0.5, SendFree: 2, SendBoard: 7, SendGame: 11}
+
+	games := []*ttt.Game{NewGame(startingGame.Boards)}
+	rng := rand.New(rand.NewPCG(1, 0))
+	for range 50 {
+		games = append(games, randomGame(rng, rng.IntN(40)))
+	}
+
+	for _, game := range games {
+		// Evaluate and EvaluateSend must equal the weighted sum of Features for
+		// Texel tuning to fit the evaluation Searcher uses.
+		want := 0.0
+		for _, feature := range ttt.Features {
+			want += *feature.Weight(&weights) * feature.Value(game)
+		}
+
+		got := weights.Evaluate(game) + weights.EvaluateSend(game)
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("after %v: got %v, want sum of features %v", game.History(), got, want)
+		}
+	}
+}
+
+func TestReadWeights(t *testing.T) {
+	buf := &bytes.Buffer{}
+	want := ttt.Weights{Meta: 50, Board: 2, BoardWin: 0.5, Floor: -10}
+	err := ttt.WriteWeights(buf, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ttt.ReadWeights(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got, err = ttt.ReadWeights(strings.NewReader(`{"meta": 50}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = ttt.DefaultWeights
+	want.Meta = 50
+	if got != want {
+		t.Errorf("got %v, want defaults with meta set %v", got, want)
+	}
+}
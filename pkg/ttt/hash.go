@@ -0,0 +1,72 @@
+package ttt
+
+// Positions are hashed by Zobrist hashing: the hash is the XOR of a key for
+// each occupied cell and player, and a key for the board the player to move is
+// forced to play in. Keys are drawn from splitmix64 seeded with zero, first two
+// for each cell along rows from the top left, for Self then Opponent, then one
+// for each forced board from -1 to 8. Searches which see both sides to move
+// add a last key when Opponent is to move.
+var (
+	zobristCells    [Cells][2]uint64
+	zobristForced   [10]uint64
+	zobristOpponent uint64
+)
+
+func init() {
+	state := uint64(0)
+	for i := range zobristCells {
+		zobristCells[i][0] = splitmix64(&state)
+		zobristCells[i][1] = splitmix64(&state)
+	}
+	for i := range zobristForced {
+		zobristForced[i] = splitmix64(&state)
+	}
+	zobristOpponent = splitmix64(&state)
+}
+
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func zobristCell(a, b, x, y uint8, player Player) uint64 {
+	keys := &zobristCells[int(b*3+y)*9+int(a*3+x)]
+	if player == Self {
+		return keys[0]
+	}
+	return keys[1]
+}
+
+// Hash returns the hash of the cells of game and the board the player to move
+// must play in.
+func (g *Game) Hash() uint64 {
+	return g.hash ^ zobristForced[g.forced+1]
+}
+
+// turnHash returns the hash of game including the player to move.
+func (g *Game) turnHash() uint64 {
+	if g.toMove == Opponent {
+		return g.Hash() ^ zobristOpponent
+	}
+	return g.Hash()
+}
+
+// Rehash recomputes the hash of the cells of game, for games whose boards were
+// changed other than by WithMove and WithoutMove.
+func (g *Game) Rehash() {
+	g.hash = 0
+	for a, col := range g.Boards {
+		for b, board := range col {
+			for x := range board.Cells {
+				for y, cell := range board.Cells[x] {
+					if cell != None {
+						g.hash ^= zobristCell(uint8(a), uint8(b), uint8(x), uint8(y), cell)
+					}
+				}
+			}
+		}
+	}
+}
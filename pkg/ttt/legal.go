@@ -0,0 +1,84 @@
+package ttt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Reasons a move is illegal, wrapped in an IllegalMoveError by Validate.
+var (
+	// ErrOutOfRange means a coordinate of the move is not in [0, 2].
+	ErrOutOfRange = errors.New("coordinates out of range")
+	// ErrGameOver means the game has been won, or no board is left to play in.
+	ErrGameOver = errors.New("game is over")
+	// ErrBoardDecided means the board of the move is won or full.
+	ErrBoardDecided = errors.New("board is already decided")
+	// ErrWrongBoard means the player to move must play in another board.
+	ErrWrongBoard = errors.New("wrong board")
+	// ErrOccupied means the cell of the move is already taken.
+	ErrOccupied = errors.New("cell is occupied")
+)
+
+// IllegalMoveError describes why a move is illegal. Err is one of the errors
+// above, so callers can check the reason with errors.Is.
+type IllegalMoveError struct {
+	Move Move
+	Err  error
+
+	// Forced is the board the player had to play in, for ErrWrongBoard.
+	Forced int
+}
+
+func (e *IllegalMoveError) Error() string {
+	if errors.Is(e.Err, ErrWrongBoard) {
+		return fmt.Sprintf("illegal move %q: %v, must play in board %d", e.Move, e.Err, e.Forced)
+	}
+	return fmt.Sprintf("illegal move %q: %v", e.Move, e.Err)
+}
+
+func (e *IllegalMoveError) Unwrap() error {
+	return e.Err
+}
+
+// Validate returns nil if the player to move may play move, or an
+// IllegalMoveError saying why not.
+func (g *Game) Validate(move Move) error {
+	a, b, x, y := move.XBoard(), move.YBoard(), move.XCell(), move.YCell()
+	illegal := func(err error) error {
+		return &IllegalMoveError{Move: move, Err: err, Forced: g.forced}
+	}
+
+	switch {
+	case a > 2 || b > 2 || x > 2 || y > 2:
+		return illegal(ErrOutOfRange)
+	case g.over():
+		return illegal(ErrGameOver)
+	case g.decided(a, b):
+		return illegal(ErrBoardDecided)
+	case g.forced != Anywhere && g.forced != int(b*3+a):
+		return illegal(ErrWrongBoard)
+	case g.Boards[a][b].Taken[x][y]:
+		return illegal(ErrOccupied)
+	}
+	return nil
+}
+
+// decided returns true if the board at a, b is won or full.
+func (g *Game) decided(a, b uint8) bool {
+	return g.Winners.Taken[a][b] || g.Boards[a][b].Full()
+}
+
+// over returns true if the game has been won, or every board is decided.
+func (g *Game) over() bool {
+	if g.Winners.Winner() != None {
+		return true
+	}
+	for a := range uint8(3) {
+		for b := range uint8(3) {
+			if !g.decided(a, b) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package ttt_test
+
+import (
+	"errors"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestGame_Validate(t *testing.T) {
+	const X, O = ttt.Self, ttt.Opponent
+
+	// Opponent has won the board at 0, 0, and Self has taken the middle of the
+	// centre board.
+	game := NewGame([3][3]*Board{
+		{
+			{{O, O, O}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, X, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+	})
+	// Opponent has won a row of boards.
+	over := NewGame([3][3]*Board{
+		{
+			{{O, O, O}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			{{O, O, O}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			{{O, O, O}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+	})
+
+	tt := []struct {
+		name   string
+		game   *ttt.Game
+		forced int
+		move   ttt.Move
+		want   error
+	}{
+		{
+			name:   "legal",
+			game:   game,
+			forced: 4,
+			move:   ttt.ToMove(1, 1, 0, 0),
+		},
+		{
+			name:   "legal anywhere",
+			game:   game,
+			forced: ttt.Anywhere,
+			move:   ttt.ToMove(2, 2, 0, 0),
+		},
+		{
+			name:   "out of range",
+			game:   game,
+			forced: 4,
+			move:   ttt.ToMove(1, 1, 3, 0),
+			want:   ttt.ErrOutOfRange,
+		},
+		{
+			name:   "occupied",
+			game:   game,
+			forced: 4,
+			move:   ttt.ToMove(1, 1, 1, 1),
+			want:   ttt.ErrOccupied,
+		},
+		{
+			name:   "wrong board",
+			game:   game,
+			forced: 4,
+			move:   ttt.ToMove(2, 2, 0, 0),
+			want:   ttt.ErrWrongBoard,
+		},
+		{
+			name:   "board decided",
+			game:   game,
+			forced: ttt.Anywhere,
+			move:   ttt.ToMove(0, 0, 2, 2),
+			want:   ttt.ErrBoardDecided,
+		},
+		{
+			name:   "game over",
+			game:   over,
+			forced: ttt.Anywhere,
+			move:   ttt.ToMove(1, 1, 1, 1),
+			want:   ttt.ErrGameOver,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.game.SetTurn(X, tc.forced)
+			err := tc.game.Validate(tc.move)
+			if tc.want == nil {
+				if err != nil {
+					t.Errorf("got error %v, want legal", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got error %v, want %v", err, tc.want)
+			}
+			var illegal *ttt.IllegalMoveError
+			if !errors.As(err, &illegal) || illegal.Move != tc.move {
+				t.Errorf("got error %v, want an IllegalMoveError for %v", err, tc.move)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package ttt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Network inputs are all zero or one, indexed from the perspective of the
+// player to move:
+//
+//	[0, 81)    cells of the player to move, along rows from the top left
+//	[81, 162)  cells of the other player
+//	[162, 171) boards won by the player to move
+//	[171, 180) boards won by the other player
+//	[180, 190) the board the player to move is forced to play in, or 189 if
+//	           they may play anywhere
+const (
+	inputOwnCells    = 0
+	inputOtherCells  = 81
+	inputOwnBoards   = 162
+	inputOtherBoards = 171
+	inputForced      = 180
+	inputAnywhere    = 189
+
+	NetworkInputs = 190
+
+	// MaxActive is the most inputs which can be one at once.
+	MaxActive = 81 + 9 + 1
+
+	// MaxHidden is the widest a hidden layer may be.
+	MaxHidden = 256
+)
+
+// Layer is a fully connected layer of a Network.
+type Layer struct {
+	In  int `json:"in"`
+	Out int `json:"out"`
+
+	// Weights holds the weight from each input to each output, with the
+	// weights of each input contiguous: Weights[i*Out+o].
+	Weights []float32 `json:"weights"`
+	Biases  []float32 `json:"biases"`
+}
+
+// Network is a small fully connected network which predicts the result of a
+// game for the player to move, between -1 for a loss and 1 for a win. Hidden
+// layers use ReLU, and the output tanh.
+type Network struct {
+	Layers []Layer `json:"layers"`
+
+	// Scale converts predictions into the units of Weights.Evaluate.
+	Scale float64 `json:"scale"`
+}
+
+// Validate checks that the layers of n fit together.
+func (n *Network) Validate() error {
+	if len(n.Layers) < 2 || len(n.Layers) > 3 {
+		return fmt.Errorf("got %d layers, want one or two hidden layers and an output", len(n.Layers))
+	}
+
+	in := NetworkInputs
+	for i, layer := range n.Layers {
+		if layer.In != in {
+			return fmt.Errorf("layer %d has %d inputs, want %d", i, layer.In, in)
+		}
+		if i < len(n.Layers)-1 && (layer.Out < 1 || layer.Out > MaxHidden) {
+			return fmt.Errorf("hidden layer %d has %d outputs, want between 1 and %d", i, layer.Out, MaxHidden)
+		}
+		if len(layer.Weights) != layer.In*layer.Out || len(layer.Biases) != layer.Out {
+			return fmt.Errorf("layer %d has %d weights and %d biases, want %d and %d",
+				i, len(layer.Weights), len(layer.Biases), layer.In*layer.Out, layer.Out)
+		}
+		in = layer.Out
+	}
+
+	if in != 1 {
+		return errors.New("output layer must have one output")
+	}
+	return nil
+}
+
+// Inputs writes the indices of the inputs which are one for game, seen by the
+// player to move, to out, and returns how many there are.
+func Inputs(game *Game, out []int) int {
+	toMove, forced := game.ToMove(), game.Forced()
+	n := 0
+	for a, col := range game.Boards {
+		for b, board := range col {
+			for x := range board.Cells {
+				for y, cell := range board.Cells[x] {
+					cellIndex := (b*3+y)*9 + a*3 + x
+					switch cell {
+					case None:
+					case toMove:
+						out[n] = inputOwnCells + cellIndex
+						n++
+					default:
+						out[n] = inputOtherCells + cellIndex
+						n++
+					}
+				}
+			}
+
+			switch game.Winners.Cells[a][b] {
+			case None:
+			case toMove:
+				out[n] = inputOwnBoards + b*3 + a
+				n++
+			default:
+				out[n] = inputOtherBoards + b*3 + a
+				n++
+			}
+		}
+	}
+
+	if forced == -1 {
+		out[n] = inputAnywhere
+	} else {
+		out[n] = inputForced + forced
+	}
+	return n + 1
+}
+
+// Predict returns the predicted result for the player to move, given the
+// indices of the inputs which are one.
+func (n *Network) Predict(active []int) float64 {
+	var buf [2][MaxHidden]float32
+	h := forwardHidden(n.Layers[:len(n.Layers)-1], active, &buf)
+
+	var out [1]float32
+	forwardDense(&n.Layers[len(n.Layers)-1], h, out[:])
+	return math.Tanh(float64(out[0]))
+}
+
+// forwardHidden returns the activations of the last of layers, each followed
+// by ReLU, where the first reads the indices of the inputs which are one. buf
+// holds the activations.
+func forwardHidden(layers []Layer, active []int, buf *[2][MaxHidden]float32) []float32 {
+	first := &layers[0]
+	h := buf[0][:first.Out]
+	copy(h, first.Biases)
+	for _, i := range active {
+		row := first.Weights[i*first.Out : (i+1)*first.Out]
+		for o, w := range row {
+			h[o] += w
+		}
+	}
+	relu(h)
+
+	for l := 1; l < len(layers); l++ {
+		out := buf[l%2][:layers[l].Out]
+		forwardDense(&layers[l], h, out)
+		relu(out)
+		h = out
+	}
+	return h
+}
+
+// forwardDense writes the outputs of layer for in to out, without activation.
+func forwardDense(layer *Layer, in, out []float32) {
+	copy(out, layer.Biases)
+	for i, v := range in {
+		if v == 0 {
+			continue
+		}
+		row := layer.Weights[i*layer.Out : (i+1)*layer.Out]
+		for o, w := range row {
+			out[o] += v * w
+		}
+	}
+}
+
+func relu(v []float32) {
+	for i, x := range v {
+		if x < 0 {
+			v[i] = 0
+		}
+	}
+}
+
+// Evaluate scores game from the perspective of Self.
+func (n *Network) Evaluate(game *Game) float64 {
+	var active [MaxActive]int
+	nActive := Inputs(game, active[:])
+
+	value := n.Predict(active[:nActive]) * n.Scale
+	if game.ToMove() != Self {
+		return -value
+	}
+	return value
+}
+
+func ReadNetwork(r io.Reader) (*Network, error) {
+	n := &Network{}
+	err := json.NewDecoder(r).Decode(n)
+	if err != nil {
+		return nil, err
+	}
+
+	err = n.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// LoadNetwork reads a network from the file at path.
+func LoadNetwork(path string) (*Network, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	n, err := ReadNetwork(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading network from %q: %w", path, err)
+	}
+	return n, nil
+}
+
+func WriteNetwork(w io.Writer, n *Network) error {
+	return json.NewEncoder(w).Encode(n)
+}
@@ -0,0 +1,123 @@
+package ttt_test
+
+import (
+	"bytes"
+	"github.com/google/go-cmp/cmp"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestInputs(t *testing.T) {
+	game := ttt.NewGame()
+	// Self takes the top row of the top left board, winning it.
+	for x := uint8(0); x < 3; x++ {
+		game.WithMove(0, 0, x, 0, ttt.Self)
+	}
+	// Opponent takes the centre cell.
+	game.WithMove(1, 1, 1, 1, ttt.Opponent)
+
+	active := make([]int, ttt.MaxActive)
+	n := ttt.Inputs(game, active)
+	got := active[:n]
+	slices.Sort(got)
+	want := []int{0, 1, 2, 81 + 40, 162, 180 + 4}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("inputs for Self: %s", diff)
+	}
+
+	game.SetTurn(ttt.Opponent, ttt.Anywhere)
+	n = ttt.Inputs(game, active)
+	got = active[:n]
+	slices.Sort(got)
+	want = []int{40, 81, 82, 83, 171, 189}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("inputs for Opponent: %s", diff)
+	}
+}
+
+func TestNetwork_Evaluate(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	network := ttt.NewNetwork([]int{16, 8}, 100, rng)
+	if err := network.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	game := ttt.NewGame()
+	game.WithMove(0, 0, 1, 1, ttt.Self)
+	game.WithMove(1, 1, 0, 0, ttt.Opponent)
+
+	// A position scores the same for the player to move whichever side they
+	// are, so Evaluate is antisymmetric under swapping the players.
+	mirror := ttt.NewGame()
+	mirror.WithMove(0, 0, 1, 1, ttt.Opponent)
+	mirror.WithMove(1, 1, 0, 0, ttt.Self)
+
+	got := network.Evaluate(game)
+	want := -network.Evaluate(mirror)
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if math.Abs(got) > 100 {
+		t.Errorf("got %v, want at most the scale", got)
+	}
+}
+
+func TestReadNetwork(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	want := ttt.NewNetwork([]int{8}, 50, rng)
+
+	buf := &bytes.Buffer{}
+	err := ttt.WriteNetwork(buf, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ttt.ReadNetwork(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	_, err = ttt.ReadNetwork(strings.NewReader(`{"layers": [{"in": 190, "out": 1}]}`))
+	if err == nil {
+		t.Error("got no error reading a network without hidden layers")
+	}
+}
+
+func TestTrainer_Epoch(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+
+	// Positions are won by whoever holds the centre cell of the centre board.
+	var examples []ttt.Example
+	for i := 0; i < 200; i++ {
+		active := []int{rng.IntN(40), 81 + 41 + rng.IntN(40), ttt.NetworkInputs - 1}
+		target := 0.8
+		if i%2 == 0 {
+			active = append(active, 40)
+		} else {
+			active = append(active, 81+40)
+			target = -0.8
+		}
+		examples = append(examples, ttt.Example{Active: active, Target: target})
+	}
+
+	trainer := ttt.NewTrainer(ttt.NewNetwork([]int{16}, 1, rng))
+	trainer.LearningRate = 0.01
+	trainer.BatchSize = 16
+
+	before := trainer.Loss(examples)
+	for epoch := 0; epoch < 50; epoch++ {
+		trainer.Epoch(examples, rng)
+	}
+	after := trainer.Loss(examples)
+
+	if after > 0.01 || after > before/10 {
+		t.Errorf("got loss %v after training from %v, want under 0.01", after, before)
+	}
+}
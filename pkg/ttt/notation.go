@@ -0,0 +1,104 @@
+package ttt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FromRowCol returns the Move for the cell at row and col of the full 9x9 grid,
+// as used by the CodinGame protocol.
+func FromRowCol(row, col uint8) Move {
+	return ToMove(col/3, row/3, col%3, row%3)
+}
+
+// RowCol returns the row and column of m on the full 9x9 grid.
+func (m Move) RowCol() (uint8, uint8) {
+	return m.YBoard()*3 + m.YCell(), m.XBoard()*3 + m.XCell()
+}
+
+// Cells is the number of cells on the 9x9 grid.
+const Cells = 81
+
+// Cell returns the index of the cell m plays in, counting along rows from the
+// top left.
+func (m Move) Cell() int {
+	row, col := m.RowCol()
+	return int(row)*9 + int(col)
+}
+
+// ParseMoves parses a move list of whitespace-separated "row col" pairs, for
+// example "4 4 3 3 0 1".
+func ParseMoves(s string) ([]Move, error) {
+	fields := strings.Fields(s)
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("move list %q has an odd number of coordinates", s)
+	}
+
+	moves := make([]Move, len(fields)/2)
+	for i := range moves {
+		row, err := parseCoordinate(fields[2*i])
+		if err != nil {
+			return nil, err
+		}
+		col, err := parseCoordinate(fields[2*i+1])
+		if err != nil {
+			return nil, err
+		}
+		moves[i] = FromRowCol(row, col)
+	}
+
+	return moves, nil
+}
+
+func parseCoordinate(s string) (uint8, error) {
+	c, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("parsing coordinate %q: %w", s, err)
+	}
+	if c > 8 {
+		return 0, fmt.Errorf("coordinate %d out of range [0, 8]", c)
+	}
+	return uint8(c), nil
+}
+
+// FormatMoves writes moves in the format read by ParseMoves.
+func FormatMoves(moves []Move) string {
+	sb := strings.Builder{}
+	for i, move := range moves {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(move.String())
+	}
+	return sb.String()
+}
+
+// Position plays moves from the start of the game, and returns the game from
+// the perspective of the player to move, so that they are Self, along with
+// their legal moves. Returns an error if a move is illegal or the game is over.
+func Position(moves []Move) (*Game, []Move, error) {
+	game := NewGame()
+
+	// Self moves first, so the player to move is Self after an even number of
+	// moves. Otherwise play the moves with the sides swapped.
+	if len(moves)%2 == 1 {
+		game.SetTurn(Opponent, Anywhere)
+	}
+	for i, move := range moves {
+		if err := game.Validate(move); err != nil {
+			return nil, nil, fmt.Errorf("after %q: %w", FormatMoves(moves[:i]), err)
+		}
+		isWin, _ := game.Play(move)
+		if isWin {
+			return nil, nil, fmt.Errorf("move %q ends the game", move)
+		}
+	}
+	legal := make([]Move, Cells)
+	nLegal := game.Moves(legal)
+	if nLegal == 0 {
+		return nil, nil, fmt.Errorf("no legal moves after %q", FormatMoves(moves))
+	}
+
+	return game, legal[:nLegal], nil
+}
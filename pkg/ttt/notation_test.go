@@ -0,0 +1,142 @@
+package ttt_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestParseMoves(t *testing.T) {
+	tt := []struct {
+		name      string
+		input     string
+		wantMoves []ttt.Move
+		wantErr   bool
+	}{
+		{
+			name:      "empty",
+			input:     "",
+			wantMoves: []ttt.Move{},
+		},
+		{
+			name:  "corners",
+			input: "0 0 0 8 8 0 8 8",
+			wantMoves: []ttt.Move{
+				ttt.ToMove(0, 0, 0, 0),
+				ttt.ToMove(2, 0, 2, 0),
+				ttt.ToMove(0, 2, 0, 2),
+				ttt.ToMove(2, 2, 2, 2),
+			},
+		},
+		{
+			name:      "row then column",
+			input:     "1 5",
+			wantMoves: []ttt.Move{ttt.ToMove(1, 0, 2, 1)},
+		},
+		{
+			name:    "odd coordinates",
+			input:   "1 5 2",
+			wantErr: true,
+		},
+		{
+			name:    "out of range",
+			input:   "1 9",
+			wantErr: true,
+		},
+		{
+			name:    "not a number",
+			input:   "a 1",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ttt.ParseMoves(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("got moves %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.wantMoves, got); diff != "" {
+				t.Error(diff)
+			}
+
+			if formatted := ttt.FormatMoves(got); formatted != tc.input {
+				t.Errorf("got formatted %q, want %q", formatted, tc.input)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	tt := []struct {
+		name      string
+		input     string
+		wantMoves int
+		wantErr   bool
+	}{
+		{
+			name:      "start",
+			input:     "",
+			wantMoves: 81,
+		},
+		{
+			name:      "forced to a board",
+			input:     "4 4",
+			wantMoves: 8,
+		},
+		{
+			name:      "two moves",
+			input:     "4 4 3 3",
+			wantMoves: 9,
+		},
+		{
+			name:    "wrong board",
+			input:   "4 4 0 0",
+			wantErr: true,
+		},
+		{
+			name:    "taken",
+			input:   "4 4 4 4",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			moves, err := ttt.ParseMoves(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			game, legal, err := ttt.Position(moves)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("got no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(legal) != tc.wantMoves {
+				t.Errorf("got %d legal moves, want %d", len(legal), tc.wantMoves)
+			}
+			if len(moves) > 0 {
+				// The player who made the last move is drawn as O.
+				row, col := moves[len(moves)-1].RowCol()
+				got := ttt.Symbol(game.Boards[col/3][row/3].Cells[col%3][row%3])
+				if got != 'O' {
+					t.Errorf("got last move by %c, want O", got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package ttt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+)
+
+// PolicyNetwork is a small fully connected network with two heads sharing its
+// hidden layers: a policy giving the logit of playing in each of the 81 cells,
+// and a value predicting the result for the player to move, between -1 for a
+// loss and 1 for a win. Its inputs are the same as a Network's.
+type PolicyNetwork struct {
+	Hidden []Layer `json:"hidden"`
+	Policy Layer   `json:"policy"`
+	Value  Layer   `json:"value"`
+}
+
+// NewPolicyNetwork returns a network with hidden layers of the given widths and
+// randomly initialized weights.
+func NewPolicyNetwork(hidden []int, rng *rand.Rand) *PolicyNetwork {
+	width := hidden[len(hidden)-1]
+	return &PolicyNetwork{
+		Hidden: newHiddenLayers(hidden, rng),
+		Policy: newLayer(width, Cells, rng),
+		Value:  newLayer(width, 1, rng),
+	}
+}
+
+// Validate checks that the layers of n fit together.
+func (n *PolicyNetwork) Validate() error {
+	if len(n.Hidden) < 1 || len(n.Hidden) > 2 {
+		return fmt.Errorf("got %d hidden layers, want one or two", len(n.Hidden))
+	}
+
+	in := NetworkInputs
+	for i, layer := range n.Hidden {
+		if layer.In != in {
+			return fmt.Errorf("hidden layer %d has %d inputs, want %d", i, layer.In, in)
+		}
+		if layer.Out < 1 || layer.Out > MaxHidden {
+			return fmt.Errorf("hidden layer %d has %d outputs, want between 1 and %d", i, layer.Out, MaxHidden)
+		}
+		in = layer.Out
+	}
+
+	heads := []struct {
+		name  string
+		layer *Layer
+		out   int
+	}{{"policy", &n.Policy, Cells}, {"value", &n.Value, 1}}
+	for _, head := range heads {
+		if head.layer.In != in || head.layer.Out != head.out {
+			return fmt.Errorf("%s layer has %d inputs and %d outputs, want %d and %d",
+				head.name, head.layer.In, head.layer.Out, in, head.out)
+		}
+	}
+
+	for _, layer := range n.layers() {
+		if len(layer.Weights) != layer.In*layer.Out || len(layer.Biases) != layer.Out {
+			return errors.New("layer weights and biases do not match its inputs and outputs")
+		}
+	}
+	return nil
+}
+
+// layers returns the hidden layers, then the policy and value layers.
+func (n *PolicyNetwork) layers() []Layer {
+	return append(append([]Layer{}, n.Hidden...), n.Policy, n.Value)
+}
+
+// Predict writes the policy logit of each cell to logits and returns the
+// predicted result for the player to move, given the indices of the inputs
+// which are one.
+func (n *PolicyNetwork) Predict(active []int, logits *[Cells]float32) float64 {
+	var buf [2][MaxHidden]float32
+	h := forwardHidden(n.Hidden, active, &buf)
+
+	forwardDense(&n.Policy, h, logits[:])
+
+	var value [1]float32
+	forwardDense(&n.Value, h, value[:])
+	return math.Tanh(float64(value[0]))
+}
+
+// Evaluate writes the prior probability of each of moves to priors, and
+// returns the predicted result for the player to move in game.
+func (n *PolicyNetwork) Evaluate(game *Game, moves []Move, priors []float64) float64 {
+	var active [MaxActive]int
+	nActive := Inputs(game, active[:])
+
+	var logits [Cells]float32
+	value := n.Predict(active[:nActive], &logits)
+	softmax(&logits, moves, priors)
+	return value
+}
+
+// softmax writes the softmax of the logits of the cells of moves to out.
+func softmax(logits *[Cells]float32, moves []Move, out []float64) {
+	highest := math.Inf(-1)
+	for _, move := range moves {
+		highest = math.Max(highest, float64(logits[move.Cell()]))
+	}
+
+	sum := 0.0
+	for i, move := range moves {
+		out[i] = math.Exp(float64(logits[move.Cell()]) - highest)
+		sum += out[i]
+	}
+	for i := range moves {
+		out[i] /= sum
+	}
+}
+
+func ReadPolicyNetwork(r io.Reader) (*PolicyNetwork, error) {
+	n := &PolicyNetwork{}
+	err := json.NewDecoder(r).Decode(n)
+	if err != nil {
+		return nil, err
+	}
+
+	err = n.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// LoadPolicyNetwork reads a policy network from the file at path.
+func LoadPolicyNetwork(path string) (*PolicyNetwork, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	n, err := ReadPolicyNetwork(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy network from %q: %w", path, err)
+	}
+	return n, nil
+}
+
+func WritePolicyNetwork(w io.Writer, n *PolicyNetwork) error {
+	return json.NewEncoder(w).Encode(n)
+}
+
+// PolicyExample is a training example for a PolicyNetwork.
+type PolicyExample struct {
+	// Active holds the indices of the inputs which are one.
+	Active []int
+
+	// Moves are the legal moves, and Policy the probability of playing each.
+	Moves  []Move
+	Policy []float64
+
+	// Value is the result to predict, between -1 and 1.
+	Value float64
+}
+
+// PolicyTrainer fits a PolicyNetwork to examples with Adam, minimizing the sum
+// of the squared error of the value and the cross-entropy of the policy over
+// the legal moves.
+type PolicyTrainer struct {
+	Network *PolicyNetwork
+
+	LearningRate float64
+	BatchSize    int
+
+	adam *adam
+}
+
+func NewPolicyTrainer(n *PolicyNetwork) *PolicyTrainer {
+	return &PolicyTrainer{Network: n, LearningRate: 1e-3, BatchSize: 256, adam: newAdam(n.layers())}
+}
+
+// Loss returns the mean squared error of the value and the mean cross-entropy
+// of the policy.
+func (t *PolicyTrainer) Loss(examples []PolicyExample) (float64, float64) {
+	valueLoss, policyLoss := 0.0, 0.0
+	priors := make([]float64, Cells)
+	for _, example := range examples {
+		var logits [Cells]float32
+		d := t.Network.Predict(example.Active, &logits) - example.Value
+		valueLoss += d * d
+
+		softmax(&logits, example.Moves, priors)
+		policyLoss += crossEntropy(example.Policy, priors)
+	}
+	n := float64(len(examples))
+	return valueLoss / n, policyLoss / n
+}
+
+func crossEntropy(target, predicted []float64) float64 {
+	loss := 0.0
+	for i, p := range target {
+		if p > 0 {
+			loss -= p * math.Log(max(predicted[i], 1e-12))
+		}
+	}
+	return loss
+}
+
+// Epoch trains on every example once, in a random order, and returns the mean
+// value and policy losses before each update.
+func (t *PolicyTrainer) Epoch(examples []PolicyExample, rng *rand.Rand) (float64, float64) {
+	order := rng.Perm(len(examples))
+
+	// The layers share their weights with the network.
+	layers := t.Network.layers()
+
+	valueLoss, policyLoss := 0.0, 0.0
+	for start := 0; start < len(order); start += t.BatchSize {
+		end := min(start+t.BatchSize, len(order))
+		t.adam.clear()
+		for _, i := range order[start:end] {
+			v, p := t.backpropagate(layers, &examples[i])
+			valueLoss += v
+			policyLoss += p
+		}
+		t.adam.update(layers, t.LearningRate, end-start)
+	}
+
+	n := float64(len(examples))
+	return valueLoss / n, policyLoss / n
+}
+
+// backpropagate adds the gradient of the loss of example to the accumulated
+// gradients of layers, the hidden layers then the policy and value layers.
+// Returns the value and policy losses.
+func (t *PolicyTrainer) backpropagate(layers []Layer, example *PolicyExample) (float64, float64) {
+	nHidden := len(layers) - 2
+	hidden, policy, value := layers[:nHidden], &layers[nHidden], &layers[nHidden+1]
+	gradients := t.adam.gradients
+
+	activations := activate(hidden, example.Active)
+	h := activations[nHidden-1]
+
+	prediction := math.Tanh(dense(value, h)[0])
+	diff := prediction - example.Value
+	delta := backpropagateDense(value, gradients[nHidden+1], h, []float64{2 * diff * (1 - prediction*prediction)})
+
+	var logits [Cells]float32
+	for i, logit := range dense(policy, h) {
+		logits[i] = float32(logit)
+	}
+	priors := make([]float64, len(example.Moves))
+	softmax(&logits, example.Moves, priors)
+
+	// The gradient of the cross-entropy of a softmax with respect to its
+	// logits is the prediction less the target.
+	policyDelta := make([]float64, Cells)
+	for i, move := range example.Moves {
+		policyDelta[move.Cell()] = priors[i] - example.Policy[i]
+	}
+	for i, d := range backpropagateDense(policy, gradients[nHidden], h, policyDelta) {
+		delta[i] += d
+	}
+
+	backpropagateHidden(hidden, gradients, activations, example.Active, delta)
+
+	return diff * diff, crossEntropy(example.Policy, priors)
+}
@@ -0,0 +1,225 @@
+package ttt
+
+import (
+	"time"
+)
+
+// Proof is the result of trying to prove a forced win.
+type Proof int8
+
+const (
+	// Unproven means the search stopped before proving or disproving a win.
+	Unproven Proof = iota
+	// Proved means the player to move has a forced win.
+	Proved
+	// Disproved means the opponent can always draw or win.
+	Disproved
+)
+
+func (p Proof) String() string {
+	switch p {
+	case Proved:
+		return "forced win"
+	case Disproved:
+		return "no forced win"
+	default:
+		return "unproven"
+	}
+}
+
+// pnInfinity is the proof or disproof number of a node which cannot be
+// proved or disproved. Sums are capped at it.
+const pnInfinity = 1 << 40
+
+// Prover tries to prove that Self has a forced win by proof-number search.
+// Draws count as failures to win.
+type Prover struct {
+	// Deadline, if set, is the time after which the search stops.
+	Deadline time.Time
+
+	// MaxNodes, if positive, is the most positions to create.
+	MaxNodes int
+
+	nodes int
+}
+
+// pnNode is a position in the proof tree. At OR nodes Self is to move, and at
+// AND nodes Opponent is.
+type pnNode struct {
+	move      Move
+	winsBoard bool
+	pn, dn    int64
+	and       bool
+	children  []*pnNode
+	expanded  bool
+}
+
+// DefaultProofNodes is the number of positions Prover searches by default.
+const DefaultProofNodes = 1 << 20
+
+func NewProver() *Prover {
+	return &Prover{MaxNodes: DefaultProofNodes}
+}
+
+// Nodes returns the number of positions created.
+func (p *Prover) Nodes() int {
+	return p.nodes
+}
+
+// Prove searches for a forced win for Self, who is to move in game and may
+// play moves. If the win is proved, also returns a winning line which ends in
+// Self winning, with the replies Opponent can make which take longest to
+// refute.
+func (p *Prover) Prove(moves []Move, game *Game) (Proof, []Move) {
+	p.nodes = 0
+	root := &pnNode{}
+	p.expandMoves(root, game, moves)
+	p.nodes++
+
+	path := make([]*pnNode, 0, Cells)
+	next := make([]Move, Cells)
+	for root.pn != 0 && root.dn != 0 {
+		if p.MaxNodes > 0 && p.nodes >= p.MaxNodes {
+			break
+		}
+		if !p.Deadline.IsZero() && time.Now().After(p.Deadline) {
+			break
+		}
+
+		// Descend to the most-proving node.
+		path = append(path[:0], root)
+		node := root
+		for node.expanded {
+			node = node.mostProving()
+			node.play(game)
+			path = append(path, node)
+		}
+
+		n := game.Moves(next)
+		p.expandMoves(node, game, next[:n])
+
+		for i := len(path) - 1; i >= 0; i-- {
+			path[i].update()
+			if i > 0 {
+				path[i].undo(game)
+			}
+		}
+	}
+
+	switch {
+	case root.pn == 0:
+		return Proved, root.line(game)
+	case root.dn == 0:
+		return Disproved, nil
+	default:
+		return Unproven, nil
+	}
+}
+
+// play makes the move into n in game.
+func (n *pnNode) play(game *Game) {
+	game.withMove(n.move)
+}
+
+// undo takes back the move into n in game.
+func (n *pnNode) undo(game *Game) {
+	game.withoutMove(n.move, n.winsBoard)
+}
+
+// expandMoves adds a child to node for each of moves, which are the moves of
+// the player to move at node, scoring children which end the game.
+func (p *Prover) expandMoves(node *pnNode, game *Game, moves []Move) {
+	player := game.ToMove()
+	node.expanded = true
+	node.children = make([]*pnNode, len(moves))
+	next := make([]Move, Cells)
+	for i, move := range moves {
+		child := &pnNode{move: move, and: !node.and, pn: 1, dn: 1}
+		isWin, winsBoard := game.withMove(move)
+		child.winsBoard = winsBoard
+		switch {
+		case isWin && player == Self:
+			child.pn, child.dn = 0, pnInfinity
+		case isWin:
+			child.pn, child.dn = pnInfinity, 0
+		case game.Moves(next) == 0 || game.Drawn():
+			// A draw, which is not a win.
+			child.pn, child.dn = pnInfinity, 0
+		}
+		game.withoutMove(move, winsBoard)
+		node.children[i] = child
+	}
+	p.nodes += len(moves)
+
+	node.update()
+}
+
+// update sets the proof and disproof numbers of node from its children.
+func (n *pnNode) update() {
+	if n.and {
+		n.pn, n.dn = 0, pnInfinity
+		for _, child := range n.children {
+			n.pn = min(n.pn+child.pn, pnInfinity)
+			n.dn = min(n.dn, child.dn)
+		}
+	} else {
+		n.pn, n.dn = pnInfinity, 0
+		for _, child := range n.children {
+			n.pn = min(n.pn, child.pn)
+			n.dn = min(n.dn+child.dn, pnInfinity)
+		}
+	}
+}
+
+// mostProving returns the child of n to search, which has the least proof
+// number at OR nodes and the least disproof number at AND nodes.
+func (n *pnNode) mostProving() *pnNode {
+	best := n.children[0]
+	for _, child := range n.children[1:] {
+		if n.and && child.dn < best.dn || !n.and && child.pn < best.pn {
+			best = child
+		}
+	}
+	return best
+}
+
+// line returns the moves from n to the end of a proved win, leaving game
+// unchanged. It follows the win with the smallest proof, and the reply with
+// the largest, which is the hardest to refute.
+func (n *pnNode) line(game *Game) []Move {
+	var line []Move
+	var path []*pnNode
+	node := n
+	for node.expanded && len(node.children) > 0 {
+		var next *pnNode
+		for _, child := range node.children {
+			if child.pn != 0 {
+				continue
+			}
+			if next == nil || node.and == (child.size() > next.size()) {
+				next = child
+			}
+		}
+		line = append(line, next.move)
+		next.play(game)
+		path = append(path, next)
+		node = next
+	}
+
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].undo(game)
+	}
+	return line
+}
+
+// size returns the number of expanded nodes below and including n.
+func (n *pnNode) size() int {
+	if !n.expanded {
+		return 0
+	}
+	size := 1
+	for _, child := range n.children {
+		size += child.size()
+	}
+	return size
+}
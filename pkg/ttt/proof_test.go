@@ -0,0 +1,78 @@
+package ttt_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestProver_Prove(t *testing.T) {
+	rng := rand.New(rand.NewPCG(4, 0))
+	for i := 0; i < 50; i++ {
+		game, moves := lateGame(rng, 12)
+		if game == nil {
+			continue
+		}
+
+		outcome, _, ok := ttt.NewSolver().Solve(moves, game)
+		if !ok {
+			t.Fatalf("%d: solver stopped", i)
+		}
+		want := ttt.Disproved
+		if outcome == ttt.Win {
+			want = ttt.Proved
+		}
+
+		before := game.String()
+		got, line := ttt.NewProver().Prove(moves, game)
+		if got != want {
+			t.Fatalf("%d: got %s, want %s\n%v", i, got, want, game)
+		}
+		if after := game.String(); after != before {
+			t.Fatalf("%d: game changed by search\n%s", i, after)
+		}
+		if got == ttt.Proved {
+			checkWinningLine(t, game, moves, line)
+		}
+	}
+}
+
+// checkWinningLine checks that line is legal from game, where Self may play
+// moves, and ends with Self winning. Plays line in game.
+func checkWinningLine(t *testing.T, game *ttt.Game, moves []ttt.Move, line []ttt.Move) {
+	t.Helper()
+
+	var player ttt.Player = ttt.Self
+	legal := make([]ttt.Move, 81)
+	n := copy(legal, moves)
+	for i, move := range line {
+		if !slices.Contains(legal[:n], move) {
+			t.Fatalf("move %d of line %q is illegal", i, ttt.FormatMoves(line))
+		}
+		isWin, _ := game.WithMove(move.XBoard(), move.YBoard(), move.XCell(), move.YCell(), player)
+		if isWin {
+			if player != ttt.Self || i != len(line)-1 {
+				t.Fatalf("line %q ends at move %d", ttt.FormatMoves(line), i)
+			}
+			return
+		}
+		n = game.LegalMoves(move.XCell(), move.YCell(), legal)
+		player = -player
+	}
+	t.Fatalf("line %q does not win", ttt.FormatMoves(line))
+}
+
+func TestProver_MaxNodes(t *testing.T) {
+	game, moves, err := ttt.Position(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := ttt.NewProver()
+	p.MaxNodes = 1000
+	got, _ := p.Prove(moves, game)
+	if got != ttt.Unproven {
+		t.Errorf("got %s, want %s", got, ttt.Unproven)
+	}
+}
@@ -0,0 +1,272 @@
+package ttt
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// PUCT searches game trees by Monte Carlo tree search guided by a
+// PolicyNetwork, as in AlphaZero: the network's value replaces random
+// rollouts, and its policy sets the prior of each move in the PUCT selection
+// rule.
+type PUCT struct {
+	Network *PolicyNetwork
+
+	// Simulations is the most simulations to run per search.
+	Simulations int
+
+	// Deadline, if set, is the time after which the search stops.
+	Deadline time.Time
+
+	// CPuct scales exploration by prior against exploitation by value.
+	CPuct float64
+
+	// NoiseFraction, if positive, mixes Dirichlet noise with concentration
+	// NoiseAlpha into the priors at the root, so self-play explores.
+	NoiseAlpha    float64
+	NoiseFraction float64
+
+	rng  *rand.Rand
+	root *puctNode
+}
+
+const (
+	DefaultSimulations = 200
+	DefaultCPuct       = 1.5
+)
+
+// NewPUCT returns a PUCT searcher without root noise. rng is used for noise and
+// sampling moves.
+func NewPUCT(network *PolicyNetwork, rng *rand.Rand) *PUCT {
+	return &PUCT{
+		Network:     network,
+		Simulations: DefaultSimulations,
+		CPuct:       DefaultCPuct,
+		NoiseAlpha:  0.3,
+		rng:         rng,
+	}
+}
+
+type puctNode struct {
+	move  Move
+	prior float64
+
+	visits int
+	// value is the sum of the results of simulations through the node, for
+	// the player who made move.
+	value float64
+
+	children []*puctNode
+	expanded bool
+	// drawn is set if the node has no legal moves.
+	drawn bool
+}
+
+func (n *puctNode) q() float64 {
+	if n.visits == 0 {
+		return 0
+	}
+	return n.value / float64(n.visits)
+}
+
+// Search runs simulations from game, where Self is to move and may play moves,
+// and writes the number of visits to each move to out. Returns the estimated
+// result for Self, between -1 and 1.
+func (p *PUCT) Search(moves []Move, game *Game, out []int) float64 {
+	p.root = &puctNode{}
+	p.expand(p.root, game, moves)
+	if p.NoiseFraction > 0 {
+		p.addNoise(p.root)
+	}
+
+	for i := 0; i < p.Simulations; i++ {
+		if i%64 == 0 && i > 0 && !p.Deadline.IsZero() && time.Now().After(p.Deadline) {
+			break
+		}
+		p.simulate(game)
+	}
+
+	for i, child := range p.root.children {
+		out[i] = child.visits
+	}
+	// The root is credited for the player who moved before Self.
+	return -p.root.q()
+}
+
+// expand adds children for moves to node, with priors from the network, and
+// returns the network's value for the player to move.
+func (p *PUCT) expand(node *puctNode, game *Game, moves []Move) float64 {
+	priors := make([]float64, len(moves))
+	value := p.Network.Evaluate(game, moves, priors)
+
+	node.children = make([]*puctNode, len(moves))
+	for i, move := range moves {
+		node.children[i] = &puctNode{move: move, prior: priors[i]}
+	}
+	node.expanded = true
+	return value
+}
+
+func (p *PUCT) addNoise(node *puctNode) {
+	noise := make([]float64, len(node.children))
+	dirichlet(p.rng, p.NoiseAlpha, noise)
+	for i, child := range node.children {
+		child.prior = (1-p.NoiseFraction)*child.prior + p.NoiseFraction*noise[i]
+	}
+}
+
+// simulate descends from the root to a leaf by the PUCT rule, evaluates it,
+// and adds the result to each node on the way.
+func (p *PUCT) simulate(game *Game) {
+	type step struct {
+		node      *puctNode
+		winsBoard bool
+	}
+	path := []step{{node: p.root}}
+
+	node := p.root
+	// value is the result for the player to move at node.
+	var value float64
+	for {
+		if !node.expanded {
+			legalMoves := make([]Move, Cells)
+			nMoves := game.Moves(legalMoves)
+			if nMoves == 0 || game.Drawn() {
+				node.drawn = true
+				value = 0
+			} else {
+				value = p.expand(node, game, legalMoves[:nMoves])
+			}
+			break
+		}
+		if node.drawn {
+			value = 0
+			break
+		}
+
+		node = p.selectChild(node)
+		isWin, winsBoard := game.withMove(node.move)
+		path = append(path, step{node: node, winsBoard: winsBoard})
+
+		if isWin {
+			// The player who just moved won.
+			value = -1
+			break
+		}
+	}
+
+	// Walk back up, undoing moves and crediting each node with the result
+	// for the player who made its move.
+	for i := len(path) - 1; i >= 0; i-- {
+		s := path[i]
+		s.node.visits++
+		s.node.value -= value
+		value = -value
+
+		if i > 0 {
+			game.withoutMove(s.node.move, s.winsBoard)
+		}
+	}
+}
+
+// selectChild returns the child of node maximizing Q + U, where U is the
+// exploration bonus from the prior.
+func (p *PUCT) selectChild(node *puctNode) *puctNode {
+	sqrtVisits := math.Sqrt(float64(max(node.visits, 1)))
+
+	var best *puctNode
+	bestScore := math.Inf(-1)
+	for _, child := range node.children {
+		score := child.q() + p.CPuct*child.prior*sqrtVisits/float64(1+child.visits)
+		if score > bestScore {
+			best, bestScore = child, score
+		}
+	}
+	return best
+}
+
+// PickMove searches game and chooses one of moves by visit count. At
+// temperature zero it plays the most visited move, and otherwise samples moves
+// with probability proportional to visits^(1/temperature).
+func (p *PUCT) PickMove(moves []Move, game *Game, temperature float64) Move {
+	visits := make([]int, len(moves))
+	p.Search(moves, game, visits)
+	return moves[SampleVisits(p.rng, visits, temperature)]
+}
+
+// SampleVisits returns the index of the move chosen from visit counts at
+// temperature, as PickMove does.
+func SampleVisits(rng *rand.Rand, visits []int, temperature float64) int {
+	best := 0
+	for i, n := range visits {
+		if n > visits[best] {
+			best = i
+		}
+	}
+	if temperature <= 0 {
+		return best
+	}
+
+	weights := make([]float64, len(visits))
+	for i, n := range visits {
+		// Scale by the most visits first to avoid overflow at low temperature.
+		weights[i] = math.Pow(float64(n)/float64(visits[best]), 1/temperature)
+	}
+	return sampleWeights(rng, weights, best)
+}
+
+// sampleWeights returns an index with probability proportional to its weight,
+// or fallback if rounding leaves none chosen.
+func sampleWeights(rng *rand.Rand, weights []float64, fallback int) int {
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+
+	r := rng.Float64() * sum
+	for i, w := range weights {
+		r -= w
+		if r < 0 {
+			return i
+		}
+	}
+	return fallback
+}
+
+// dirichlet writes a sample from the symmetric Dirichlet distribution with
+// concentration alpha to out.
+func dirichlet(rng *rand.Rand, alpha float64, out []float64) {
+	sum := 0.0
+	for i := range out {
+		out[i] = gamma(rng, alpha)
+		sum += out[i]
+	}
+	for i := range out {
+		out[i] /= sum
+	}
+}
+
+// gamma samples the gamma distribution with shape alpha and scale one, by
+// Marsaglia and Tsang's method.
+func gamma(rng *rand.Rand, alpha float64) float64 {
+	if alpha < 1 {
+		// Boost the shape above one, then scale back down.
+		return gamma(rng, alpha+1) * math.Pow(rng.Float64(), 1/alpha)
+	}
+
+	d := alpha - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package ttt_test
+
+import (
+	"bytes"
+	"github.com/google/go-cmp/cmp"
+	"math"
+	"math/rand/v2"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// nearWin returns a game where Self has won the top left and top middle
+// boards, and wins the game by taking the top right cell of the top right
+// board.
+func nearWin() (*ttt.Game, []ttt.Move, ttt.Move) {
+	game := ttt.NewGame()
+	for a := uint8(0); a < 2; a++ {
+		for x := uint8(0); x < 3; x++ {
+			game.WithMove(a, 0, x, 0, ttt.Self)
+		}
+	}
+	game.WithMove(2, 0, 0, 0, ttt.Self)
+	game.WithMove(2, 0, 1, 0, ttt.Self)
+	game.WithMove(2, 0, 1, 1, ttt.Opponent)
+	game.SetTurn(ttt.Self, 2)
+
+	moves := make([]ttt.Move, 81)
+	n := game.Moves(moves)
+	return game, moves[:n], ttt.ToMove(2, 0, 2, 0)
+}
+
+func TestPUCT_PickMove(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	game, moves, want := nearWin()
+	before := game.String()
+
+	p := ttt.NewPUCT(ttt.NewPolicyNetwork([]int{8}, rng), rng)
+	p.Simulations = 100
+	p.NoiseFraction = 0.25
+
+	got := p.PickMove(moves, game, 0)
+	if got != want {
+		t.Errorf("got %v, want winning move %v", got, want)
+	}
+	if after := game.String(); after != before {
+		t.Errorf("search changed the game from\n%s\nto\n%s", before, after)
+	}
+
+	visits := make([]int, len(moves))
+	value := p.Search(moves, game, visits)
+	if value < 0.5 {
+		t.Errorf("got value %v, want near 1 for a won position", value)
+	}
+	total := 0
+	for _, n := range visits {
+		total += n
+	}
+	if total != p.Simulations {
+		t.Errorf("got %d visits to root moves, want %d", total, p.Simulations)
+	}
+}
+
+func TestSampleVisits(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	visits := []int{10, 30, 0, 60}
+
+	if got := ttt.SampleVisits(rng, visits, 0); got != 3 {
+		t.Errorf("got %d at temperature zero, want most visited 3", got)
+	}
+
+	counts := make([]int, len(visits))
+	for i := 0; i < 10000; i++ {
+		counts[ttt.SampleVisits(rng, visits, 1)]++
+	}
+	for i, n := range visits {
+		got := float64(counts[i]) / 10000
+		want := float64(n) / 100
+		if math.Abs(got-want) > 0.02 {
+			t.Errorf("move %d: got frequency %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestReadPolicyNetwork(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	want := ttt.NewPolicyNetwork([]int{8, 4}, rng)
+
+	buf := &bytes.Buffer{}
+	err := ttt.WritePolicyNetwork(buf, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ttt.ReadPolicyNetwork(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestPolicyTrainer_Epoch(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	game, moves, win := nearWin()
+
+	active := make([]int, ttt.MaxActive)
+	n := ttt.Inputs(game, active)
+	policy := make([]float64, len(moves))
+	for i, move := range moves {
+		if move == win {
+			policy[i] = 1
+		}
+	}
+	examples := []ttt.PolicyExample{{Active: active[:n], Moves: moves, Policy: policy, Value: 1}}
+
+	network := ttt.NewPolicyNetwork([]int{16}, rng)
+	trainer := ttt.NewPolicyTrainer(network)
+	trainer.LearningRate = 0.01
+
+	for epoch := 0; epoch < 200; epoch++ {
+		trainer.Epoch(examples, rng)
+	}
+
+	valueLoss, policyLoss := trainer.Loss(examples)
+	if valueLoss > 0.01 || policyLoss > 0.1 {
+		t.Errorf("got value loss %v and policy loss %v, want under 0.01 and 0.1", valueLoss, policyLoss)
+	}
+
+	priors := make([]float64, len(moves))
+	value := network.Evaluate(game, moves, priors)
+	if value < 0.9 {
+		t.Errorf("got value %v, want near 1", value)
+	}
+	for i, move := range moves {
+		if move == win && priors[i] < 0.9 {
+			t.Errorf("got prior %v for the winning move, want near 1", priors[i])
+		}
+	}
+}
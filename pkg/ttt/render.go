@@ -0,0 +1,58 @@
+package ttt
+
+import (
+	"strings"
+)
+
+// Symbol returns the character used to draw player's cells: X for Self and O
+// for Opponent.
+func Symbol(player Player) byte {
+	switch player {
+	case Self:
+		return 'X'
+	case Opponent:
+		return 'O'
+	default:
+		return '.'
+	}
+}
+
+func (b *Board) String() string {
+	sb := strings.Builder{}
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			if x > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteByte(Symbol(b.Cells[x][y]))
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// String draws the full 9x9 grid with rows from top to bottom, followed by the
+// meta-board of won boards. Self is drawn as X and Opponent as O.
+func (g *Game) String() string {
+	sb := strings.Builder{}
+	for row := 0; row < 9; row++ {
+		if row > 0 && row%3 == 0 {
+			sb.WriteString("------+-------+------\n")
+		}
+		for col := 0; col < 9; col++ {
+			if col > 0 {
+				if col%3 == 0 {
+					sb.WriteString(" | ")
+				} else {
+					sb.WriteByte(' ')
+				}
+			}
+			sb.WriteByte(Symbol(g.Boards[col/3][row/3].Cells[col%3][row%3]))
+		}
+		sb.WriteByte('\n')
+	}
+
+	sb.WriteByte('\n')
+	sb.WriteString(g.Winners.String())
+	return sb.String()
+}
@@ -0,0 +1,473 @@
+package ttt
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"time"
+)
+
+// checkInterval is the number of nodes searched between checks of the deadline.
+const checkInterval = 1024
+
+// Searcher searches game trees for the best move.
+type Searcher struct {
+	// Deadline, if set, is the time after which the search stops.
+	Deadline time.Time
+
+	// MaxNodes, if positive, is the most positions to evaluate, counted as by
+	// Nodes, after which the search stops.
+	MaxNodes int
+
+	Weights Weights
+
+	// Network, if set, evaluates positions instead of Weights. Weights still
+	// sets the bonus for winning boards and the floor.
+	Network *Network
+
+	// SolveEmpty, if positive, is the most empty cells at which PickMove first
+	// tries to prove the outcome with a Solver, limited to SolveNodes positions.
+	SolveEmpty int
+	SolveNodes int
+
+	// Tree, if set, records the positions searched by the last search.
+	Tree *SearchTree
+
+	// table holds the positions already searched, by their turnHash.
+	table map[uint64]searched
+
+	nodes   int
+	stopped bool
+
+	proven  bool
+	outcome Outcome
+}
+
+// searched is a transposition table entry. Value is exact for the position
+// searched to depth, or a bound on it if the search was cut off.
+type searched struct {
+	value float64
+	depth int
+	bound bound
+	best  Move
+}
+
+const (
+	// DefaultSolveEmpty and DefaultSolveNodes keep proofs to a fraction of a
+	// second. Positions with 16 empty cells rarely need more than 10^5 nodes.
+	DefaultSolveEmpty = 16
+	DefaultSolveNodes = 1 << 18
+)
+
+// NewSearcher returns a Searcher using DefaultWeights with no deadline, which
+// solves positions with few empty cells exactly.
+func NewSearcher() *Searcher {
+	return &Searcher{
+		Weights:    DefaultWeights,
+		SolveEmpty: DefaultSolveEmpty,
+		SolveNodes: DefaultSolveNodes,
+	}
+}
+
+func Minimax(game *Game, depth int) float64 {
+	return NewSearcher().Minimax(game, depth)
+}
+
+func PickMove(moves []Move, game *Game, depth int) Move {
+	return NewSearcher().PickMove(moves, game, depth)
+}
+
+// Stopped returns true if the search was cut short by the deadline, in which
+// case results are unreliable.
+func (s *Searcher) Stopped() bool {
+	return s.stopped
+}
+
+// Nodes returns the number of positions evaluated. The count starts again
+// when PickMove or IterativeDeepening falls back from the solver to the
+// heuristic search.
+func (s *Searcher) Nodes() int {
+	return s.nodes
+}
+
+// Proven returns the outcome for Self of the last position searched, and
+// whether it was proven by solving to the end of the game.
+func (s *Searcher) Proven() (Outcome, bool) {
+	return s.outcome, s.proven
+}
+
+// IterativeDeepening runs PickMove at increasing depths up to maxDepth until
+// the deadline passes. Depth one is always completed. Returns the choice and
+// depth of the deepest completed search, or the number of empty cells if the
+// position was solved.
+func (s *Searcher) IterativeDeepening(moves []Move, game *Game, maxDepth int) (Move, int) {
+	if choice, ok := s.solve(moves, game); ok {
+		return choice, game.Empty()
+	}
+	s.restart()
+
+	// Search to depth one however little time or how few nodes are left, so
+	// that the move played has always been searched.
+	deadline, maxNodes := s.Deadline, s.MaxNodes
+	s.Deadline, s.MaxNodes = time.Time{}, 0
+	choice := s.pickMove(moves, game, 1)
+	s.Deadline, s.MaxNodes = deadline, maxNodes
+	completed := 1
+
+	for depth := 2; depth <= maxDepth; depth++ {
+		if !s.Deadline.IsZero() && time.Now().After(s.Deadline) {
+			break
+		}
+
+		next := s.pickMove(moves, game, depth)
+		if s.stopped {
+			break
+		}
+		choice = next
+		completed = depth
+	}
+
+	return choice, completed
+}
+
+// Minimax returns the value for Self of game searched to depth, with the
+// player to move and the board they must play in taken from game.
+func (s *Searcher) Minimax(game *Game, depth int) float64 {
+	return s.alphaBeta(game, depth, math.Inf(-1.0), math.Inf(1.0))
+}
+
+// alphaBeta returns the value for Self of game searched to depth if it lies
+// between alpha and beta. Otherwise it returns a bound beyond the window: at
+// most alpha, or at least beta.
+func (s *Searcher) alphaBeta(game *Game, depth int, alpha, beta float64) float64 {
+	s.nodes++
+	if s.nodes%checkInterval == 0 && !s.Deadline.IsZero() && time.Now().After(s.Deadline) ||
+		s.MaxNodes > 0 && s.nodes > s.MaxNodes {
+		s.stopped = true
+	}
+	if s.stopped {
+		return 0
+	}
+
+	if game.Drawn() {
+		return 0
+	}
+
+	if depth == 0 {
+		if s.Network != nil {
+			return s.Network.Evaluate(game)
+		}
+		return s.Weights.Evaluate(game) + s.Weights.EvaluateSend(game)
+	}
+
+	s.Tree.bounds(alpha, beta)
+	if s.table == nil {
+		s.table = make(map[uint64]searched)
+	}
+	key := game.turnHash()
+	entry, found := s.table[key]
+	if found && entry.depth == depth {
+		s.Tree.ttHit()
+		switch entry.bound {
+		case exact:
+			return entry.value
+		case lower:
+			alpha = math.Max(alpha, entry.value)
+		case upper:
+			beta = math.Min(beta, entry.value)
+		}
+		if alpha >= beta {
+			return entry.value
+		}
+	}
+
+	// Search the best move from a previous search first, then the moves which
+	// send the opponent to the least useful boards.
+	legalMoves := make([]Move, Cells)
+	nLegalMoves := game.Moves(legalMoves)
+	legalMoves = legalMoves[:nLegalMoves]
+	rest := legalMoves
+	if i := slices.Index(legalMoves, entry.best); found && i >= 0 {
+		legalMoves[0], legalMoves[i] = legalMoves[i], legalMoves[0]
+		rest = legalMoves[1:]
+	}
+	game.OrderMoves(rest)
+
+	originalAlpha, originalBeta := alpha, beta
+	player := game.ToMove()
+	// Self's moves are worth at least the floor, and Opponent's at most +Inf.
+	value := math.Inf(1.0)
+	if player == Self {
+		value = s.Weights.Floor
+	}
+	var best Move
+	for _, nextMove := range legalMoves {
+		s.Tree.enter(nextMove, player)
+		isWin, winsBoard := game.withMove(nextMove)
+		if isWin {
+			// The player to move can win the game.
+			game.withoutMove(nextMove, winsBoard)
+			value = math.Inf(int(player))
+			s.Tree.leave(value)
+			s.Tree.cutoff()
+			return value
+		}
+
+		// Winning a board is worth BoardWin to the winner, so search the reply
+		// with the window moved by as much.
+		bonus := 0.0
+		if winsBoard {
+			bonus = float64(player) * s.Weights.BoardWin
+		}
+		nextMoveValue := s.alphaBeta(game, depth-1, alpha-bonus, beta-bonus) + bonus
+		game.withoutMove(nextMove, winsBoard)
+		s.Tree.leave(nextMoveValue)
+
+		if s.stopped {
+			return 0
+		}
+
+		if player == Self && nextMoveValue > value || player == Opponent && nextMoveValue < value {
+			value, best = nextMoveValue, nextMove
+		}
+		if player == Self {
+			alpha = math.Max(alpha, value)
+		} else {
+			beta = math.Min(beta, value)
+		}
+		if alpha >= beta {
+			s.Tree.cutoff()
+			break
+		}
+	}
+
+	entry = searched{value: value, depth: depth, best: best}
+	switch {
+	case value <= originalAlpha:
+		entry.bound = upper
+	case value >= originalBeta:
+		entry.bound = lower
+	}
+	s.table[key] = entry
+
+	return value
+}
+
+// PickMove returns the best of moves for Self. Positions with at most
+// SolveEmpty empty cells are solved exactly if possible, and otherwise searched
+// to depth.
+func (s *Searcher) PickMove(moves []Move, game *Game, depth int) Move {
+	if choice, ok := s.solve(moves, game); ok {
+		return choice
+	}
+	s.restart()
+	return s.pickMove(moves, game, depth)
+}
+
+// restart starts counting nodes again for a heuristic search, so that those
+// the solver searched do not count against MaxNodes.
+func (s *Searcher) restart() {
+	s.nodes, s.stopped = 0, false
+}
+
+// solve tries to prove the outcome of game, and returns the move to play if
+// it is a win or draw. Proven losses are left to the heuristic search, which
+// plays for the opponent to go wrong.
+func (s *Searcher) solve(moves []Move, game *Game) (Move, bool) {
+	s.proven = false
+	if s.SolveEmpty <= 0 || game.Empty() > s.SolveEmpty {
+		return 0, false
+	}
+
+	solver := NewSolver()
+	solver.Deadline = s.Deadline
+	solver.MaxNodes = s.SolveNodes
+	solver.Tree = s.Tree
+	outcome, choice, ok := solver.Solve(moves, game)
+	s.nodes += solver.Nodes()
+	if !ok {
+		return 0, false
+	}
+
+	s.proven, s.outcome = true, outcome
+	if debug {
+		_, _ = fmt.Fprintf(os.Stderr, "Proven %s in %d nodes: %s\n", outcome, solver.Nodes(), choice)
+	}
+	return choice, outcome != Loss
+}
+
+func (s *Searcher) pickMove(moves []Move, game *Game, depth int) Move {
+	values := make([]float64, len(moves))
+	s.ScoreMoves(moves, game, depth, values)
+
+	// Default to first valid move.
+	choice := moves[0]
+	value := math.Inf(-1.0)
+
+	for i, moveValue := range values {
+		if moveValue > value {
+			choice = moves[i]
+			value = moveValue
+		}
+	}
+	return choice
+}
+
+// ScoreMoves writes the value of each of moves for Self to out. If a move wins
+// the game it scores +Inf, and the moves after it are not searched and score
+// -Inf.
+func (s *Searcher) ScoreMoves(moves []Move, game *Game, depth int, out []float64) {
+	for i := range moves {
+		out[i] = math.Inf(-1.0)
+	}
+	s.Tree.reset()
+
+	for i, move := range moves {
+		if debug {
+			_, _ = fmt.Fprintf(os.Stderr, "%d/%d: %s", i, len(moves), move)
+		}
+
+		a := move.XBoard()
+		b := move.YBoard()
+		x := move.XCell()
+		y := move.YCell()
+
+		s.Tree.enter(move, Self)
+		isWin, winsBoard := game.WithMove(a, b, x, y, Self)
+		if isWin {
+			out[i] = math.Inf(1.0)
+			game.WithoutMove(a, b, x, y, Self, winsBoard)
+			s.Tree.leave(out[i])
+			s.Tree.cutoff()
+			s.Tree.finish(out[i])
+			if debug {
+				_, _ = fmt.Fprintln(os.Stderr, "Wins game")
+			}
+			return
+		}
+
+		moveValue := s.Minimax(game, depth-1)
+		game.WithoutMove(a, b, x, y, Self, winsBoard)
+
+		if winsBoard {
+			moveValue += s.Weights.BoardWin
+		}
+
+		out[i] = moveValue
+		s.Tree.leave(moveValue)
+
+		if debug {
+			_, _ = fmt.Fprintf(os.Stderr, ": %f\n", moveValue)
+			if winsBoard {
+				_, _ = fmt.Fprintln(os.Stderr, "Wins board")
+			}
+		}
+	}
+	s.Tree.finish(slices.Max(out[:len(moves)]))
+}
+
+// MoveAnalysis is what a search to some depth found for one legal move.
+type MoveAnalysis struct {
+	Move Move
+
+	// Score is the value of the move for Self.
+	Score float64
+
+	// Line is the sequence of moves the search expects, starting with Move, if
+	// it was found.
+	Line []Move
+
+	// WinsBoard and WinsGame are set if the move wins a board or the game.
+	WinsBoard bool
+	WinsGame  bool
+
+	// GivesBoard is set if the move lets Opponent win a board with their reply.
+	GivesBoard bool
+}
+
+// Analyze scores each of moves for Self, searching to depth, and returns them
+// best first. The line of each of the best multiPV moves is found, or of every
+// move if multiPV is not positive. Unlike ScoreMoves, moves after one which
+// wins the game are still searched.
+func (s *Searcher) Analyze(moves []Move, game *Game, depth, multiPV int) []MoveAnalysis {
+	analyses := make([]MoveAnalysis, len(moves))
+	for i, move := range moves {
+		analysis := MoveAnalysis{Move: move}
+		analysis.WinsGame, analysis.WinsBoard = game.withMove(move)
+		if analysis.WinsGame {
+			analysis.Score = math.Inf(1.0)
+		} else {
+			analysis.GivesBoard = game.Send().WinsBoard
+			analysis.Score = s.Minimax(game, depth-1)
+			if analysis.WinsBoard {
+				analysis.Score += s.Weights.BoardWin
+			}
+		}
+		game.withoutMove(move, analysis.WinsBoard)
+		analyses[i] = analysis
+	}
+
+	slices.SortStableFunc(analyses, func(a, b MoveAnalysis) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	for i := range analyses {
+		if multiPV > 0 && i >= multiPV {
+			break
+		}
+		analyses[i].Line = s.Variation(analyses[i].Move, game, depth)
+	}
+	return analyses
+}
+
+// Variation returns the line a search to depth expects after Self plays move:
+// move, then the reply each player would choose, until depth moves are made or
+// the game ends.
+func (s *Searcher) Variation(move Move, game *Game, depth int) []Move {
+	line := []Move{move}
+	isWin, winsBoard := game.withMove(move)
+	wins := []bool{winsBoard}
+	next := make([]Move, Cells)
+	for remaining := depth - 1; remaining > 0 && !isWin; remaining-- {
+		n := game.Moves(next)
+		if n == 0 || game.Drawn() {
+			break
+		}
+		reply := s.bestReply(game, next[:n], remaining)
+		line = append(line, reply)
+		isWin, winsBoard = game.withMove(reply)
+		wins = append(wins, winsBoard)
+	}
+
+	for i := len(line) - 1; i >= 0; i-- {
+		game.withoutMove(line[i], wins[i])
+	}
+	return line
+}
+
+// bestReply returns the one of moves which is best for the player to move in
+// game, searching to depth.
+func (s *Searcher) bestReply(game *Game, moves []Move, depth int) Move {
+	player := game.ToMove()
+	best, bestValue := moves[0], math.Inf(-1.0)
+	for _, move := range moves {
+		isWin, winsBoard := game.withMove(move)
+		value := math.Inf(1.0)
+		if !isWin {
+			// Minimax scores for Self, so negate it when Opponent moves.
+			value = s.Minimax(game, depth-1)
+			if winsBoard {
+				value += float64(player) * s.Weights.BoardWin
+			}
+			value *= float64(player)
+		}
+		game.withoutMove(move, winsBoard)
+
+		if value > bestValue {
+			best, bestValue = move, value
+		}
+	}
+	return best
+}
@@ -0,0 +1,211 @@
+package ttt_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestSearcher_IterativeDeepening(t *testing.T) {
+	tt := []struct {
+		name      string
+		deadline  time.Time
+		maxDepth  int
+		wantDepth int
+	}{
+		{
+			name:      "no deadline",
+			maxDepth:  3,
+			wantDepth: 3,
+		},
+		{
+			name:      "deadline passed",
+			deadline:  time.Now().Add(-time.Second),
+			maxDepth:  8,
+			wantDepth: 1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			game := NewGame(startingGame.Boards)
+			moves := make([]ttt.Move, 81)
+			nMoves := game.LegalMoves(2, 0, moves)
+
+			s := ttt.NewSearcher()
+			s.Deadline = tc.deadline
+			_, got := s.IterativeDeepening(moves[:nMoves], game, tc.maxDepth)
+			if got != tc.wantDepth {
+				t.Errorf("got depth %d, want %d", got, tc.wantDepth)
+			}
+		})
+	}
+}
+
+func TestSearcher_IterativeDeepening_Unsolved(t *testing.T) {
+	rng := rand.New(rand.NewPCG(6, 0))
+	for i := 0; i < 10; i++ {
+		game, moves := lateGame(rng, 14)
+		if game == nil {
+			continue
+		}
+
+		// The solver gives up after more nodes than the heuristic search may
+		// use, which must still complete depth one.
+		s := ttt.NewSearcher()
+		s.SolveNodes = 100
+		s.MaxNodes = 10
+		got, depth := s.IterativeDeepening(moves, game, 1)
+		if _, proven := s.Proven(); proven {
+			continue
+		}
+
+		scores := make([]float64, len(moves))
+		ttt.NewSearcher().ScoreMoves(moves, game, 1, scores)
+		want := moves[slices.Index(scores, slices.Max(scores))]
+		if got != want || depth != 1 {
+			t.Errorf("%d: got %v at depth %d, want %v at depth 1", i, got, depth, want)
+		}
+	}
+}
+
+func TestSearcher_Analyze(t *testing.T) {
+	game, moves, win := nearWin()
+	before := game.String()
+
+	analyses := ttt.NewSearcher().Analyze(moves, game, 3, 2)
+	if after := game.String(); after != before {
+		t.Fatalf("game changed by analysis:\n%s\nwant:\n%s", after, before)
+	}
+	if len(analyses) != len(moves) {
+		t.Fatalf("got %d analyses, want %d", len(analyses), len(moves))
+	}
+
+	first := analyses[0]
+	if first.Move != win || !first.WinsGame || !first.WinsBoard || !math.IsInf(first.Score, 1) {
+		t.Errorf("got first %+v, want winning move %v", first, win)
+	}
+	if !slices.Equal(first.Line, []ttt.Move{win}) {
+		t.Errorf("got line %v after winning move, want just the move", first.Line)
+	}
+
+	// Other moves score as ScoreMoves would score them.
+	others := slices.DeleteFunc(slices.Clone(moves), func(m ttt.Move) bool { return m == win })
+	want := make([]float64, len(others))
+	ttt.NewSearcher().ScoreMoves(others, game, 3, want)
+	for i, analysis := range analyses[1:] {
+		if i > 0 && analysis.Score > analyses[i].Score {
+			t.Errorf("got %v scoring %v after %v scoring %v", analysis.Move, analysis.Score, analyses[i].Move, analyses[i].Score)
+		}
+		if got := want[slices.Index(others, analysis.Move)]; analysis.Score != got {
+			t.Errorf("got score %v for %v, want %v", analysis.Score, analysis.Move, got)
+		}
+	}
+
+	second := analyses[1]
+	if len(second.Line) == 0 || len(second.Line) > 3 || second.Line[0] != second.Move {
+		t.Errorf("got line %v for %v, want up to 3 moves starting with it", second.Line, second.Move)
+	}
+	for _, analysis := range analyses[2:] {
+		if analysis.Line != nil {
+			t.Errorf("got line %v for %v beyond multipv", analysis.Line, analysis.Move)
+		}
+	}
+}
+
+// fullMinimax returns the value for Self of game searched to depth, trying
+// every move at every position.
+func fullMinimax(game *ttt.Game, weights ttt.Weights, depth int) float64 {
+	if game.Drawn() {
+		return 0
+	}
+	if depth == 0 {
+		return weights.Evaluate(game) + weights.EvaluateSend(game)
+	}
+
+	player := game.ToMove()
+	value := math.Inf(1)
+	if player == ttt.Self {
+		value = weights.Floor
+	}
+	moves := make([]ttt.Move, 81)
+	for _, move := range moves[:game.Moves(moves)] {
+		a, b, x, y := move.XBoard(), move.YBoard(), move.XCell(), move.YCell()
+		isWin, winsBoard := game.WithMove(a, b, x, y, player)
+		var moveValue float64
+		if isWin {
+			moveValue = math.Inf(int(player))
+		} else {
+			moveValue = fullMinimax(game, weights, depth-1)
+			if winsBoard {
+				moveValue += float64(player) * weights.BoardWin
+			}
+		}
+		game.WithoutMove(a, b, x, y, player, winsBoard)
+
+		if player == ttt.Self {
+			value = math.Max(value, moveValue)
+		} else {
+			value = math.Min(value, moveValue)
+		}
+	}
+	return value
+}
+
+func TestSearcher_Minimax(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 0))
+	cutoffs, hits := 0, 0
+	for i := 0; i < 20; i++ {
+		game, moves, err := ttt.Position(randomGame(rng, 10+rng.IntN(40)).History())
+		if err != nil || len(moves) == 0 {
+			continue
+		}
+
+		s := ttt.NewSearcher()
+		s.Tree = ttt.NewSearchTree(ttt.Cells)
+		got := make([]float64, len(moves))
+		s.ScoreMoves(moves, game, 4, got)
+
+		// Pruning and the transposition table leave the score of each move
+		// as a search of every line would find it.
+		for j, move := range moves {
+			isWin, winsBoard := game.WithMove(move.XBoard(), move.YBoard(), move.XCell(), move.YCell(), ttt.Self)
+			want := math.Inf(1)
+			if !isWin {
+				want = fullMinimax(game, s.Weights, 3)
+				if winsBoard {
+					want += s.Weights.BoardWin
+				}
+			}
+			game.WithoutMove(move.XBoard(), move.YBoard(), move.XCell(), move.YCell(), ttt.Self, winsBoard)
+
+			if got[j] != want {
+				t.Errorf("%d: got %v for %v, want %v", i, got[j], move, want)
+			}
+			if isWin {
+				break
+			}
+		}
+
+		var count func(node *ttt.SearchNode)
+		count = func(node *ttt.SearchNode) {
+			if node.Cutoff {
+				cutoffs++
+			}
+			if node.TTHit {
+				hits++
+			}
+			for _, child := range node.Children {
+				count(child)
+			}
+		}
+		count(s.Tree.Root)
+	}
+
+	if cutoffs == 0 || hits == 0 {
+		t.Errorf("got %d cutoffs and %d transposition table hits, want some of each", cutoffs, hits)
+	}
+}
@@ -0,0 +1,85 @@
+package ttt
+
+import (
+	"slices"
+)
+
+// Send describes the board a player is sent to, from the perspective of the
+// player who must play there.
+type Send struct {
+	// Free is set if the board is won or full, so they may play anywhere.
+	Free bool
+
+	// WinsBoard is set if they can win a board with their next move.
+	WinsBoard bool
+
+	// WinsGame is set if they can win the game with their next move.
+	WinsGame bool
+}
+
+// Send analyses the board the player to move must play in.
+func (g *Game) Send() Send {
+	player := g.toMove
+	send := Send{Free: g.forced == Anywhere}
+	if !send.Free {
+		send.WinsBoard, send.WinsGame = g.threatens(uint8(g.forced%3), uint8(g.forced/3), player)
+		return send
+	}
+
+	for a := range uint8(3) {
+		for b := range uint8(3) {
+			if g.Winners.Taken[a][b] {
+				continue
+			}
+			winsBoard, winsGame := g.threatens(a, b, player)
+			send.WinsBoard = send.WinsBoard || winsBoard
+			send.WinsGame = send.WinsGame || winsGame
+		}
+	}
+	return send
+}
+
+// threatens returns whether player can win the undecided board at a, b in one
+// move, and whether doing so wins the game.
+func (g *Game) threatens(a, b uint8, player Player) (bool, bool) {
+	if g.Boards[a][b].Info().Threats[side(player)] == 0 {
+		return false, false
+	}
+	return true, g.Winners.Info().Threats[side(player)]&(1<<(3*a+b)) != 0
+}
+
+// cost ranks a Send by how much it helps the player sent, from zero for
+// a board with nothing to gain.
+func (s Send) cost() int {
+	cost := 0
+	if s.Free {
+		cost++
+	}
+	if s.WinsBoard {
+		cost += 2
+	}
+	if s.WinsGame {
+		cost += 4
+	}
+	return cost
+}
+
+// OrderMoves sorts moves for the player to move so that moves which win the game come
+// first, followed by those which send the opponent to the least useful boards.
+// The order of moves which are equally good is kept.
+func (g *Game) OrderMoves(moves []Move) {
+	costs := make([]int, Cells)
+	for _, move := range moves {
+		isWin, winsBoard := g.withMove(move)
+		if isWin {
+			costs[move.Cell()] = -1
+		} else {
+			costs[move.Cell()] = g.Send().cost()
+		}
+		g.withoutMove(move, winsBoard)
+	}
+
+	slices.SortStableFunc(moves, func(m, n Move) int {
+		return costs[m.Cell()] - costs[n.Cell()]
+	})
+}
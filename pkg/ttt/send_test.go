@@ -0,0 +1,139 @@
+package ttt_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestGame_Send(t *testing.T) {
+	const X, O = ttt.Self, ttt.Opponent
+
+	// Opponent has won the boards at 0, 0 and 1, 0 and threatens the board at
+	// 2, 0, which would win the game. Self has won the board at 0, 1 and
+	// threatens the one at 1, 1.
+	game := NewGame([3][3]*Board{
+		{
+			{{O, O, O}, {0, 0, 0}, {0, 0, 0}},
+			{{X, X, X}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			{{O, O, O}, {0, 0, 0}, {0, 0, 0}},
+			{{X, X, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			{{O, O, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+	})
+
+	tt := []struct {
+		name   string
+		a, b   uint8
+		player ttt.Player
+		want   ttt.Send
+	}{
+		{
+			name:   "quiet board",
+			a:      2,
+			b:      2,
+			player: O,
+			want:   ttt.Send{},
+		},
+		{
+			name:   "wins board",
+			a:      1,
+			b:      1,
+			player: X,
+			want:   ttt.Send{WinsBoard: true},
+		},
+		{
+			name:   "wins game",
+			a:      2,
+			b:      0,
+			player: O,
+			want:   ttt.Send{WinsBoard: true, WinsGame: true},
+		},
+		{
+			name:   "other player's threat",
+			a:      2,
+			b:      0,
+			player: X,
+			want:   ttt.Send{},
+		},
+		{
+			name:   "free move",
+			a:      0,
+			b:      0,
+			player: O,
+			want:   ttt.Send{Free: true, WinsBoard: true, WinsGame: true},
+		},
+		{
+			name:   "free move for the other player",
+			a:      0,
+			b:      1,
+			player: X,
+			want:   ttt.Send{Free: true, WinsBoard: true},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			forced := int(tc.b*3 + tc.a)
+			if game.Winners.Taken[tc.a][tc.b] {
+				forced = ttt.Anywhere
+			}
+			game.SetTurn(tc.player, forced)
+			got := game.Send()
+			if got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGame_OrderMoves(t *testing.T) {
+	const X, O = ttt.Self, ttt.Opponent
+
+	// Self is to move in the board at 0, 2. Playing in cells 0, 0 and 1, 0
+	// gives Opponent a free move, from which they can win the game. Playing in
+	// cell 2, 0 sends them to the board they can win the game from, and in
+	// cell 1, 1 to a board they can win.
+	game := NewGame([3][3]*Board{
+		{
+			{{O, O, O}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			{{O, O, O}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			{{O, O, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+			{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+	})
+	game.Boards[1][1] = NewBoard(&Board{{O, 0, 0}, {0, 0, 0}, {0, 0, O}})
+
+	game.SetTurn(X, 6)
+	moves := make([]ttt.Move, 81)
+	n := game.Moves(moves)
+	moves = moves[:n]
+	game.OrderMoves(moves)
+
+	want := []ttt.Move{
+		ttt.ToMove(0, 2, 1, 1),
+		ttt.ToMove(0, 2, 2, 0),
+		ttt.ToMove(0, 2, 0, 0),
+		ttt.ToMove(0, 2, 1, 0),
+	}
+	if diff := cmp.Diff(want, moves[len(moves)-len(want):]); diff != "" {
+		t.Error(diff)
+	}
+}
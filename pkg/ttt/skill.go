@@ -0,0 +1,90 @@
+package ttt
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// Skill weakens move selection, for playing against people. The search deepens
+// up to Depth until it has evaluated Nodes positions, and the move is sampled
+// from the scores of the deepest completed search.
+type Skill struct {
+	Depth int
+
+	// Nodes, if positive, is the most positions to evaluate.
+	Nodes int
+
+	// Temperature, in evaluation units, is how far below the best score a move
+	// is played e times less often than the best. At zero the best is always
+	// played.
+	Temperature float64
+
+	// Elo is the approximate rating of the level relative to the random agent.
+	Elo int
+}
+
+// SkillLevels are the skill levels from weakest to strongest. Their Elo, to the
+// nearest 10, was fit over a round robin between the levels and the random
+// agent, 50 games per pairing from the starting position, with
+//
+//	battle tournament -n 50 --random-plies 0 random skill:level=1 ... skill:level=8
+var SkillLevels = []Skill{
+	{Depth: 1, Temperature: 200, Elo: 200},
+	{Depth: 1, Temperature: 50, Elo: 330},
+	{Depth: 1, Temperature: 10, Elo: 390},
+	{Depth: 2, Temperature: 30, Elo: 560},
+	{Depth: 4, Nodes: 20000, Temperature: 5, Elo: 670},
+	{Depth: 4, Nodes: 100000, Temperature: 2, Elo: 750},
+	{Depth: 5, Nodes: 300000, Temperature: 1, Elo: 910},
+	{Depth: 6, Nodes: 1000000, Elo: 1060},
+}
+
+// PickMoveSkill returns one of moves for Self chosen at skill, with random
+// choices made with rng. It sets MaxNodes to allow skill.Nodes more positions.
+// Moves which win the game are always played, and if not even a search to
+// depth one completes the move is uniformly random.
+func (s *Searcher) PickMoveSkill(moves []Move, game *Game, skill Skill, rng *rand.Rand) Move {
+	if skill.Nodes > 0 {
+		s.MaxNodes = s.nodes + skill.Nodes
+	}
+
+	scores := make([]float64, len(moves))
+	next := make([]float64, len(moves))
+	completed := false
+	for depth := 1; depth <= skill.Depth; depth++ {
+		s.ScoreMoves(moves, game, depth, next)
+		if s.stopped {
+			break
+		}
+		copy(scores, next)
+		completed = true
+
+		if !s.Deadline.IsZero() && time.Now().After(s.Deadline) {
+			break
+		}
+	}
+
+	if !completed {
+		return moves[rng.IntN(len(moves))]
+	}
+	return moves[SampleScores(rng, scores, skill.Temperature)]
+}
+
+// SampleScores returns the index of the move chosen from scores at
+// temperature, with probability proportional to exp(score/temperature). At
+// temperature zero, or if any move wins the game, it returns the first best.
+func SampleScores(rng *rand.Rand, scores []float64, temperature float64) int {
+	best := slices.Index(scores, slices.Max(scores))
+	if temperature <= 0 || math.IsInf(scores[best], 0) {
+		return best
+	}
+
+	weights := make([]float64, len(scores))
+	for i, score := range scores {
+		// Subtract the best score first to avoid overflow.
+		weights[i] = math.Exp((score - scores[best]) / temperature)
+	}
+	return sampleWeights(rng, weights, best)
+}
@@ -0,0 +1,93 @@
+package ttt_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestSampleScores(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+
+	tt := []struct {
+		name        string
+		scores      []float64
+		temperature float64
+		want        int
+	}{
+		{name: "zero temperature", scores: []float64{1, 3, 2, 3}, temperature: 0, want: 1},
+		{name: "winning move", scores: []float64{1, math.Inf(1), 2}, temperature: 1000, want: 1},
+		{name: "every move loses", scores: []float64{math.Inf(-1), math.Inf(-1)}, temperature: 10, want: 0},
+		{name: "losing moves never played", scores: []float64{math.Inf(-1), 0, math.Inf(-1)}, temperature: 1000, want: 1},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			for range 100 {
+				if got := ttt.SampleScores(rng, tc.scores, tc.temperature); got != tc.want {
+					t.Fatalf("got %d, want %d", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSampleScores_Temperature(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+
+	// A move 10 below the best at temperature 10 is played e times less often.
+	scores := []float64{0, -10}
+	counts := make([]int, len(scores))
+	for range 10000 {
+		counts[ttt.SampleScores(rng, scores, 10)]++
+	}
+
+	got := float64(counts[0]) / float64(counts[1])
+	if math.Abs(got-math.E) > 0.2 {
+		t.Errorf("got ratio %.2f, want about %.2f", got, math.E)
+	}
+}
+
+func TestSearcher_PickMoveSkill(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+
+	for level, skill := range ttt.SkillLevels {
+		for range 5 {
+			// Position plays as Self whoever is to move.
+			game, moves, err := ttt.Position(randomGame(rng, 10+rng.IntN(20)).History())
+			if err != nil {
+				continue
+			}
+
+			s := ttt.NewSearcher()
+			got := s.PickMoveSkill(moves, game, skill, rng)
+			if !slices.Contains(moves, got) {
+				t.Errorf("level %d: got %v, not one of %v", level+1, got, moves)
+			}
+			if skill.Nodes > 0 && s.Nodes() > skill.Nodes+1 {
+				t.Errorf("level %d: searched %d nodes, want at most %d", level+1, s.Nodes(), skill.Nodes+1)
+			}
+		}
+	}
+}
+
+func TestSearcher_PickMoveSkill_Unfinished(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	game, moves, err := ttt.Position(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Even at temperature zero, a move is uniformly random if depth one is not
+	// searched in time.
+	skill := ttt.Skill{Depth: 1, Nodes: 1}
+	seen := make(map[ttt.Move]bool)
+	for range 20 {
+		seen[ttt.NewSearcher().PickMoveSkill(moves, game, skill, rng)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("got only %v in 20 moves, want random moves", seen)
+	}
+}
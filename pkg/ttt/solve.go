@@ -0,0 +1,199 @@
+package ttt
+
+import (
+	"time"
+)
+
+// Outcome is the result of a game under perfect play, for the player to move.
+type Outcome int8
+
+const (
+	Loss Outcome = -1
+	Draw Outcome = 0
+	Win  Outcome = 1
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Loss:
+		return "loss"
+	case Draw:
+		return "draw"
+	case Win:
+		return "win"
+	default:
+		return "unknown"
+	}
+}
+
+// Empty returns the number of cells which may still be played in, in boards
+// which are neither won nor full.
+func (g *Game) Empty() int {
+	empty := 0
+	for a, col := range g.Boards {
+		for b, board := range col {
+			if g.Winners.Taken[a][b] {
+				continue
+			}
+			for _, cells := range board.Taken {
+				for _, taken := range cells {
+					if !taken {
+						empty++
+					}
+				}
+			}
+		}
+	}
+	return empty
+}
+
+// Solver proves the outcome of positions by alpha-beta search to the end of
+// the game, with a transposition table.
+type Solver struct {
+	// Deadline, if set, is the time after which the search stops.
+	Deadline time.Time
+
+	// MaxNodes, if positive, is the most positions to search.
+	MaxNodes int
+
+	// Tree, if set, records the positions searched by the last search.
+	Tree *SearchTree
+
+	table   map[uint64]solved
+	nodes   int
+	stopped bool
+}
+
+// solved is a transposition table entry. Outcome is exact, or a bound on the
+// outcome if the search was cut off.
+type solved struct {
+	outcome Outcome
+	bound   bound
+	best    Move
+}
+
+type bound int8
+
+const (
+	exact bound = iota
+	lower
+	upper
+)
+
+func NewSolver() *Solver {
+	return &Solver{table: make(map[uint64]solved)}
+}
+
+// Nodes returns the number of positions searched.
+func (s *Solver) Nodes() int {
+	return s.nodes
+}
+
+// Solve returns the outcome for Self, who is to move in game and may play
+// moves, and a move which achieves it. Returns false if the search stopped at
+// the deadline or node limit first.
+func (s *Solver) Solve(moves []Move, game *Game) (Outcome, Move, bool) {
+	s.stopped = false
+	s.Tree.reset()
+	outcome, best := s.negamax(game, moves, Loss, Win)
+	if s.stopped {
+		return Draw, moves[0], false
+	}
+	s.Tree.finish(float64(outcome))
+	return outcome, best, true
+}
+
+// negamax returns the outcome for the player to move, who may play moves,
+// within the window from alpha to beta, and the best move.
+func (s *Solver) negamax(game *Game, moves []Move, alpha, beta Outcome) (Outcome, Move) {
+	s.nodes++
+	if s.nodes%checkInterval == 0 && !s.Deadline.IsZero() && time.Now().After(s.Deadline) ||
+		s.MaxNodes > 0 && s.nodes > s.MaxNodes {
+		s.stopped = true
+	}
+	if s.stopped {
+		return Draw, 0
+	}
+
+	if len(moves) == 0 || game.Drawn() {
+		// Every board is decided or full without three boards in a row, or
+		// neither player can complete a line of boards.
+		return Draw, 0
+	}
+
+	s.Tree.window(alpha, beta, game.ToMove())
+	key := game.turnHash()
+	entry, found := s.table[key]
+	if found {
+		s.Tree.ttHit()
+		switch entry.bound {
+		case exact:
+			return entry.outcome, entry.best
+		case lower:
+			alpha = max(alpha, entry.outcome)
+		case upper:
+			beta = min(beta, entry.outcome)
+		}
+		if alpha >= beta {
+			return entry.outcome, entry.best
+		}
+	}
+
+	// Search the best move from a previous search first, then the moves which
+	// send the opponent to the least useful boards.
+	order := make([]Move, 0, len(moves))
+	if found {
+		order = append(order, entry.best)
+	}
+	rest := len(order)
+	for _, move := range moves {
+		if !found || move != entry.best {
+			order = append(order, move)
+		}
+	}
+	game.OrderMoves(order[rest:])
+
+	originalAlpha := alpha
+	best, bestMove := Loss-1, order[0]
+	next := make([]Move, Cells)
+	player := game.ToMove()
+	for _, move := range order {
+		s.Tree.enter(move, player)
+		isWin, winsBoard := game.withMove(move)
+
+		var outcome Outcome
+		if isWin {
+			outcome = Win
+		} else {
+			n := game.Moves(next)
+			reply, _ := s.negamax(game, next[:n], -beta, -alpha)
+			outcome = -reply
+		}
+		game.withoutMove(move, winsBoard)
+		s.Tree.leave(float64(outcome) * float64(player))
+
+		if s.stopped {
+			return Draw, 0
+		}
+
+		if outcome > best {
+			best, bestMove = outcome, move
+		}
+		alpha = max(alpha, outcome)
+		if alpha >= beta {
+			s.Tree.cutoff()
+			break
+		}
+	}
+
+	entry = solved{outcome: best, best: bestMove}
+	switch {
+	case best <= originalAlpha:
+		entry.bound = upper
+	case best >= beta:
+		entry.bound = lower
+	}
+	s.table[key] = entry
+
+	return best, bestMove
+}
@@ -0,0 +1,152 @@
+package ttt_test
+
+import (
+	"math/rand/v2"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// lateGame plays random moves until at most empty cells remain playable, and
+// returns the game from the perspective of the player to move, with their
+// legal moves. Returns nil if the game ended first.
+func lateGame(rng *rand.Rand, empty int) (*ttt.Game, []ttt.Move) {
+	game := ttt.NewGame()
+	moves := make([]ttt.Move, 81)
+	n := game.Moves(moves)
+	for game.Empty() > empty {
+		if n == 0 {
+			return nil, nil
+		}
+		isWin, _ := game.Play(moves[rng.IntN(n)])
+		if isWin {
+			return nil, nil
+		}
+		n = game.Moves(moves)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	if game.ToMove() == ttt.Opponent {
+		// Swap sides so that Self is to move.
+		swapped := ttt.NewGame()
+		for a, col := range game.Boards {
+			for b, board := range col {
+				for x := range board.Cells {
+					for y, cell := range board.Cells[x] {
+						if cell != ttt.None {
+							swapped.WithMove(uint8(a), uint8(b), uint8(x), uint8(y), -cell)
+						}
+					}
+				}
+			}
+		}
+		swapped.SetTurn(ttt.Self, game.Forced())
+		game = swapped
+	}
+	return game, moves[:n]
+}
+
+// bruteForce returns the outcome for player, who may play moves, by searching
+// every line to the end of the game.
+func bruteForce(game *ttt.Game, player ttt.Player, moves []ttt.Move) ttt.Outcome {
+	if len(moves) == 0 {
+		return ttt.Draw
+	}
+
+	best := ttt.Loss
+	next := make([]ttt.Move, 81)
+	for _, move := range moves {
+		a, b, x, y := move.XBoard(), move.YBoard(), move.XCell(), move.YCell()
+		isWin, winsBoard := game.WithMove(a, b, x, y, player)
+		outcome := ttt.Win
+		if !isWin {
+			n := game.LegalMoves(x, y, next)
+			outcome = -bruteForce(game, -player, next[:n])
+		}
+		game.WithoutMove(a, b, x, y, player, winsBoard)
+		best = max(best, outcome)
+		if best == ttt.Win {
+			break
+		}
+	}
+	return best
+}
+
+func TestSolver_Solve(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	for i := 0; i < 50; i++ {
+		game, moves := lateGame(rng, 10)
+		if game == nil {
+			continue
+		}
+
+		want := bruteForce(game, ttt.Self, moves)
+		got, move, ok := ttt.NewSolver().Solve(moves, game)
+		if !ok {
+			t.Fatalf("%d: solver stopped", i)
+		}
+		if got != want {
+			t.Fatalf("%d: got %s, want %s\n%v", i, got, want, game)
+		}
+
+		// The move must achieve the outcome.
+		a, b, x, y := move.XBoard(), move.YBoard(), move.XCell(), move.YCell()
+		isWin, winsBoard := game.WithMove(a, b, x, y, ttt.Self)
+		after := ttt.Win
+		if !isWin {
+			next := make([]ttt.Move, 81)
+			n := game.LegalMoves(x, y, next)
+			after = -bruteForce(game, ttt.Opponent, next[:n])
+		}
+		game.WithoutMove(a, b, x, y, ttt.Self, winsBoard)
+		if after != want {
+			t.Errorf("%d: move %s gives %s, want %s", i, move, after, want)
+		}
+	}
+}
+
+func TestSolver_MaxNodes(t *testing.T) {
+	rng := rand.New(rand.NewPCG(2, 0))
+	game, moves := lateGame(rng, 30)
+	if game == nil {
+		t.Fatal("game ended early")
+	}
+
+	s := ttt.NewSolver()
+	s.MaxNodes = 100
+	if _, _, ok := s.Solve(moves, game); ok {
+		t.Error("got proven, want stopped at node limit")
+	}
+}
+
+func TestSearcher_Proven(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 0))
+	for i := 0; i < 20; i++ {
+		game, moves := lateGame(rng, 10)
+		if game == nil {
+			continue
+		}
+
+		want := bruteForce(game, ttt.Self, moves)
+		s := ttt.NewSearcher()
+		s.PickMove(moves, game, 1)
+		got, proven := s.Proven()
+		if !proven {
+			t.Fatalf("%d: not proven with %d empty cells", i, game.Empty())
+		}
+		if got != want {
+			t.Errorf("%d: got %s, want %s", i, got, want)
+		}
+	}
+
+	game, moves := lateGame(rng, 40)
+	if game == nil {
+		t.Fatal("game ended early")
+	}
+	s := ttt.NewSearcher()
+	s.PickMove(moves, game, 1)
+	if _, proven := s.Proven(); proven {
+		t.Errorf("proven with %d empty cells", game.Empty())
+	}
+}
@@ -0,0 +1,121 @@
+package ttt
+
+// Symmetry is one of the eight rotations and reflections of the grid, applied
+// to the boards and to the cells within each board at once, which map every
+// position to one which plays the same. Symmetries 0 to 3 turn the grid a
+// quarter turn at a time, and 4 to 7 reflect it in x before turning it.
+type Symmetry uint8
+
+const (
+	// Identity is the symmetry which leaves positions as they are.
+	Identity Symmetry = 0
+
+	// Symmetries is the number of symmetries.
+	Symmetries = 8
+)
+
+// point maps the coordinates x, y of a board or a cell through s.
+func (s Symmetry) point(x, y uint8) (uint8, uint8) {
+	if s >= 4 {
+		x = 2 - x
+	}
+	for range s % 4 {
+		x, y = 2-y, x
+	}
+	return x, y
+}
+
+// Inverse returns the symmetry which undoes s.
+func (s Symmetry) Inverse() Symmetry {
+	if s >= 4 {
+		// Reflections undo themselves.
+		return s
+	}
+	return (4 - s) % 4
+}
+
+// Move maps m through s.
+func (s Symmetry) Move(m Move) Move {
+	a, b := s.point(m.XBoard(), m.YBoard())
+	x, y := s.point(m.XCell(), m.YCell())
+	return ToMove(a, b, x, y)
+}
+
+// Forced maps the index of a forced board through s.
+func (s Symmetry) Forced(forced int) int {
+	if forced == Anywhere {
+		return Anywhere
+	}
+	a, b := s.point(uint8(forced%3), uint8(forced/3))
+	return int(b*3 + a)
+}
+
+// Game returns a copy of g mapped through s, with the same player to move.
+// The moves of g cannot be taken back in the copy.
+func (s Symmetry) Game(g *Game) *Game {
+	t := NewGame()
+	for a, col := range g.Boards {
+		for b, board := range col {
+			ta, tb := s.point(uint8(a), uint8(b))
+			for x, cells := range board.Cells {
+				for y, cell := range cells {
+					if cell != None {
+						tx, ty := s.point(uint8(x), uint8(y))
+						t.Boards[ta][tb].WithMove(tx, ty, cell)
+					}
+				}
+			}
+			// Copy winners rather than finding them again, since a board may
+			// have more than one line.
+			if winner := g.Winners.Cells[a][b]; winner != None {
+				t.Winners.WithMove(ta, tb, winner)
+			}
+		}
+	}
+	t.Rehash()
+	t.SetTurn(g.toMove, s.Forced(g.forced))
+	return t
+}
+
+// SymmetricHash returns the Hash of g mapped through s.
+func (g *Game) SymmetricHash(s Symmetry) uint64 {
+	if s == Identity {
+		return g.Hash()
+	}
+
+	hash := uint64(0)
+	for a, col := range g.Boards {
+		for b, board := range col {
+			ta, tb := s.point(uint8(a), uint8(b))
+			for x, cells := range board.Cells {
+				for y, cell := range cells {
+					if cell != None {
+						tx, ty := s.point(uint8(x), uint8(y))
+						hash ^= zobristCell(ta, tb, tx, ty, cell)
+					}
+				}
+			}
+		}
+	}
+	return hash ^ zobristForced[s.Forced(g.forced)+1]
+}
+
+// CanonicalHash returns the least hash of g under any symmetry, and the
+// symmetry which gives it. Positions which are the same up to symmetry have
+// the same canonical hash.
+func (g *Game) CanonicalHash() (uint64, Symmetry) {
+	best, bestSymmetry := g.Hash(), Identity
+	for s := Symmetry(1); s < Symmetries; s++ {
+		if hash := g.SymmetricHash(s); hash < best {
+			best, bestSymmetry = hash, s
+		}
+	}
+	return best, bestSymmetry
+}
+
+// Canonical returns the canonical form of g, which is the same for every
+// position symmetric to g, and the symmetry which maps g to it.
+func (g *Game) Canonical() (*Game, Symmetry) {
+	_, s := g.CanonicalHash()
+	return s.Game(g), s
+}
@@ -0,0 +1,101 @@
+package ttt_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// randomGame plays up to plies random moves from the start.
+func randomGame(rng *rand.Rand, plies int) *ttt.Game {
+	game := ttt.NewGame()
+	moves := make([]ttt.Move, 81)
+	for range plies {
+		n := game.Moves(moves)
+		if n == 0 {
+			break
+		}
+		if isWin, _ := game.Play(moves[rng.IntN(n)]); isWin {
+			break
+		}
+	}
+	return game
+}
+
+func TestSymmetry_Move(t *testing.T) {
+	for s := ttt.Identity; s < ttt.Symmetries; s++ {
+		for row := range uint8(9) {
+			for col := range uint8(9) {
+				m := ttt.FromRowCol(row, col)
+				if got := s.Inverse().Move(s.Move(m)); got != m {
+					t.Errorf("symmetry %d: got %v after mapping %v and back", s, got, m)
+				}
+			}
+		}
+	}
+
+	// A move on no line of symmetry has eight different images.
+	m := ttt.FromRowCol(0, 1)
+	images := make(map[ttt.Move]bool)
+	for s := ttt.Identity; s < ttt.Symmetries; s++ {
+		images[s.Move(m)] = true
+	}
+	if len(images) != ttt.Symmetries {
+		t.Errorf("got %d images of %v, want %d", len(images), m, ttt.Symmetries)
+	}
+}
+
+func TestSymmetry_Game(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	moves := make([]ttt.Move, 81)
+	mapped := make([]ttt.Move, 81)
+	for i := range 50 {
+		game := randomGame(rng, i)
+		n := game.Moves(moves)
+
+		for s := ttt.Identity; s < ttt.Symmetries; s++ {
+			got := s.Game(game)
+
+			// The legal moves are the legal moves of game, mapped.
+			want := make([]ttt.Move, n)
+			for j, move := range moves[:n] {
+				want[j] = s.Move(move)
+			}
+			slices.Sort(want)
+			nMapped := got.Moves(mapped)
+			slices.Sort(mapped[:nMapped])
+			if !slices.Equal(mapped[:nMapped], want) {
+				t.Fatalf("after %v, symmetry %d: got moves %v, want %v", game.History(), s, mapped[:nMapped], want)
+			}
+
+			back := s.Inverse().Game(got)
+			if back.Hash() != game.Hash() || back.ToMove() != game.ToMove() {
+				t.Fatalf("after %v, symmetry %d: got a different game after mapping back", game.History(), s)
+			}
+			if h, want := got.Hash(), game.SymmetricHash(s); h != want {
+				t.Errorf("after %v, symmetry %d: got hash %x, want SymmetricHash %x", game.History(), s, h, want)
+			}
+		}
+	}
+}
+
+func TestGame_Canonical(t *testing.T) {
+	rng := rand.New(rand.NewPCG(2, 0))
+	for i := range 50 {
+		game := randomGame(rng, i)
+		canonical, symmetry := game.Canonical()
+		want := canonical.Hash()
+		if got := symmetry.Game(game).Hash(); got != want {
+			t.Errorf("after %v: got hash %x mapping through %d, want %x", game.History(), got, symmetry, want)
+		}
+
+		// Every symmetric position has the same canonical form.
+		for s := ttt.Identity; s < ttt.Symmetries; s++ {
+			other, _ := s.Game(game).Canonical()
+			if got := other.Hash(); got != want {
+				t.Errorf("after %v, symmetry %d: got canonical hash %x, want %x", game.History(), s, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package ttt
+
+// BoardStates is the number of ways to fill a 3x3 board with nothing, Self or
+// Opponent in each cell.
+const BoardStates = 19683
+
+// BoardInfo describes a 3x3 board state, precomputed for every state.
+type BoardInfo struct {
+	// Winner is the player with three in a row, or None. If both players have
+	// a line, which cannot happen in play, it is the player with the first
+	// line, looking at columns, then rows, then diagonals.
+	Winner Player
+
+	// Full is set if every cell is taken.
+	Full bool
+
+	// Open holds, for Self then Opponent, whether the player has a line
+	// without cells of the other, so that they can still complete it.
+	Open [2]bool
+
+	// Dead is set if the board has no winner and every line has cells of both
+	// players, so that neither can win it.
+	Dead bool
+
+	// Threats holds, for Self then Opponent, the empty cells where the player
+	// would complete a line. Cell x, y is bit 3*x+y.
+	Threats [2]uint16
+
+	// Value is the sum over every line of its cells, counting Self as 1 and
+	// Opponent as -1. Dead lines, which have cells of both players, count
+	// zero.
+	Value int8
+}
+
+// boardTable holds the BoardInfo of every board state, indexed by
+// Board.State.
+var boardTable [BoardStates]BoardInfo
+
+// pow3 holds the place value of each cell in a board state. Cell x, y is
+// digit 3*x+y.
+var pow3 = [9]uint16{1, 3, 9, 27, 81, 243, 729, 2187, 6561}
+
+// boardLines lists the cells of each line, as x, y pairs: columns, then rows,
+// then the diagonals.
+var boardLines = [8][3][2]uint8{
+	{{0, 0}, {0, 1}, {0, 2}},
+	{{1, 0}, {1, 1}, {1, 2}},
+	{{2, 0}, {2, 1}, {2, 2}},
+	{{0, 0}, {1, 0}, {2, 0}},
+	{{0, 1}, {1, 1}, {2, 1}},
+	{{0, 2}, {1, 2}, {2, 2}},
+	{{0, 0}, {1, 1}, {2, 2}},
+	{{0, 2}, {1, 1}, {2, 0}},
+}
+
+// lineMasks holds the cells of each of boardLines as a bitmask, with cell x, y
+// as bit 3*x+y.
+var lineMasks [8]uint16
+
+func init() {
+	for state := range boardTable {
+		boardTable[state] = newBoardInfo(uint16(state))
+	}
+	for i, line := range boardLines {
+		for _, cell := range line {
+			lineMasks[i] |= 1 << (3*cell[0] + cell[1])
+		}
+	}
+}
+
+// side returns the index of player in BoardInfo fields held for Self then
+// Opponent.
+func side(player Player) int {
+	if player == Self {
+		return 0
+	}
+	return 1
+}
+
+// digit returns the value of player's cells in a board state.
+func digit(player Player) uint16 {
+	switch {
+	case player > 0:
+		return 1
+	case player < 0:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// stateCells returns the player in each cell of state.
+func stateCells(state uint16) [3][3]Player {
+	var cells [3][3]Player
+	for i := range 9 {
+		switch state / pow3[i] % 3 {
+		case 1:
+			cells[i/3][i%3] = Self
+		case 2:
+			cells[i/3][i%3] = Opponent
+		}
+	}
+	return cells
+}
+
+func newBoardInfo(state uint16) BoardInfo {
+	cells := stateCells(state)
+	info := BoardInfo{Full: true}
+	for _, line := range boardLines {
+		sum, hasSelf, hasOpponent := int8(0), false, false
+		for _, cell := range line {
+			switch cells[cell[0]][cell[1]] {
+			case Self:
+				sum++
+				hasSelf = true
+			case Opponent:
+				sum--
+				hasOpponent = true
+			}
+		}
+		if !hasOpponent {
+			info.Open[0] = true
+		}
+		if !hasSelf {
+			info.Open[1] = true
+		}
+		if hasSelf && hasOpponent {
+			continue
+		}
+		info.Value += sum
+
+		if info.Winner == None && (sum == 3 || sum == -3) {
+			info.Winner = sum / 3
+		}
+
+		// A line with two of a player's cells and an empty cell is a threat.
+		threatened := -1
+		switch sum {
+		case 2:
+			threatened = 0
+		case -2:
+			threatened = 1
+		}
+		if threatened != -1 {
+			for _, cell := range line {
+				if cells[cell[0]][cell[1]] == None {
+					info.Threats[threatened] |= 1 << (3*cell[0] + cell[1])
+				}
+			}
+		}
+	}
+
+	for i := range 9 {
+		if state/pow3[i]%3 == 0 {
+			info.Full = false
+		}
+	}
+	info.Dead = info.Winner == None && !info.Open[0] && !info.Open[1]
+	return info
+}
+
+// Info returns the precomputed description of b.
+func (b *Board) Info() *BoardInfo {
+	return &boardTable[b.State]
+}
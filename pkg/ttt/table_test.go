@@ -0,0 +1,115 @@
+package ttt_test
+
+import (
+	"math/rand/v2"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+// boardFromState fills a board with the cells of a ternary state, where digit
+// 3*x+y is 0 for an empty cell, 1 for Self and 2 for Opponent.
+func boardFromState(state int) *ttt.Board {
+	b := &ttt.Board{}
+	for x := uint8(0); x < 3; x++ {
+		for y := uint8(0); y < 3; y++ {
+			switch state % 3 {
+			case 1:
+				b.WithMove(x, y, ttt.Self)
+			case 2:
+				b.WithMove(x, y, ttt.Opponent)
+			}
+			state /= 3
+		}
+	}
+	return b
+}
+
+// linesOf returns the cells of each line of b: columns, rows, then diagonals.
+func linesOf(b *ttt.Board) [8][3]ttt.Player {
+	c := b.Cells
+	return [8][3]ttt.Player{
+		{c[0][0], c[0][1], c[0][2]},
+		{c[1][0], c[1][1], c[1][2]},
+		{c[2][0], c[2][1], c[2][2]},
+		{c[0][0], c[1][0], c[2][0]},
+		{c[0][1], c[1][1], c[2][1]},
+		{c[0][2], c[1][2], c[2][2]},
+		{c[0][0], c[1][1], c[2][2]},
+		{c[0][2], c[1][1], c[2][0]},
+	}
+}
+
+func TestBoard_Info(t *testing.T) {
+	for state := 0; state < ttt.BoardStates; state++ {
+		b := boardFromState(state)
+		if int(b.State) != state {
+			t.Fatalf("got state %d, want %d", b.State, state)
+		}
+		got := b.Info()
+
+		want := ttt.BoardInfo{Full: true}
+		for _, line := range linesOf(b) {
+			sum := line[0] + line[1] + line[2]
+			if want.Winner == ttt.None && (sum == 3 || sum == -3) {
+				want.Winner = line[0]
+			}
+			hasSelf := line[0] == ttt.Self || line[1] == ttt.Self || line[2] == ttt.Self
+			hasOpponent := line[0] == ttt.Opponent || line[1] == ttt.Opponent || line[2] == ttt.Opponent
+			want.Open[0] = want.Open[0] || !hasOpponent
+			want.Open[1] = want.Open[1] || !hasSelf
+			if !hasSelf || !hasOpponent {
+				want.Value += sum
+			}
+		}
+		want.Dead = want.Winner == ttt.None && !want.Open[0] && !want.Open[1]
+
+		for x := uint8(0); x < 3; x++ {
+			for y := uint8(0); y < 3; y++ {
+				if b.Taken[x][y] {
+					continue
+				}
+				want.Full = false
+
+				// A threat is a cell which would complete a line.
+				before := linesOf(b)
+				for i, player := range []ttt.Player{ttt.Self, ttt.Opponent} {
+					b.Cells[x][y] = player
+					for j, line := range linesOf(b) {
+						if line != before[j] && line == [3]ttt.Player{player, player, player} {
+							want.Threats[i] |= 1 << (3*x + y)
+						}
+					}
+					b.Cells[x][y] = ttt.None
+				}
+			}
+		}
+
+		if *got != want {
+			t.Fatalf("state %d:\n%v\ngot %+v, want %+v", state, b, *got, want)
+		}
+	}
+}
+
+func TestBoard_WithoutMove(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 0))
+	for i := 0; i < 1000; i++ {
+		state := rng.IntN(ttt.BoardStates)
+		b := boardFromState(state)
+		for x := uint8(0); x < 3; x++ {
+			for y := uint8(0); y < 3; y++ {
+				if b.Taken[x][y] {
+					continue
+				}
+				wasWon := b.Winner() != ttt.None
+				wins := b.WithMove(x, y, ttt.Opponent)
+				if wins != (!wasWon && b.Winner() != ttt.None) {
+					t.Fatalf("state %d: got win %v playing %d %d", state, wins, x, y)
+				}
+				b.WithoutMove(x, y, ttt.Opponent)
+				if int(b.State) != state {
+					t.Fatalf("got state %d after undo, want %d", b.State, state)
+				}
+			}
+		}
+	}
+}
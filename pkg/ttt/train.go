@@ -0,0 +1,282 @@
+package ttt
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// Example is a training example for a Network.
+type Example struct {
+	// Active holds the indices of the inputs which are one.
+	Active []int
+
+	// Target is the result to predict, between -1 and 1.
+	Target float64
+}
+
+// NewNetwork returns a network with hidden layers of the given widths and
+// randomly initialized weights.
+func NewNetwork(hidden []int, scale float64, rng *rand.Rand) *Network {
+	layers := newHiddenLayers(hidden, rng)
+	return &Network{
+		Layers: append(layers, newLayer(hidden[len(hidden)-1], 1, rng)),
+		Scale:  scale,
+	}
+}
+
+// newHiddenLayers returns randomly initialized hidden layers of the given
+// widths, the first reading the network inputs.
+func newHiddenLayers(widths []int, rng *rand.Rand) []Layer {
+	var layers []Layer
+	in := NetworkInputs
+	for _, out := range widths {
+		layers = append(layers, newLayer(in, out, rng))
+		in = out
+	}
+	return layers
+}
+
+// newLayer returns a layer with He initialization, counting the inputs which
+// are typically active rather than all of them for the sparse first layer.
+func newLayer(in, out int, rng *rand.Rand) Layer {
+	layer := Layer{
+		In:      in,
+		Out:     out,
+		Weights: make([]float32, in*out),
+		Biases:  make([]float32, out),
+	}
+
+	fanIn := float64(in)
+	if in == NetworkInputs {
+		fanIn = 20
+	}
+	std := math.Sqrt(2 / fanIn)
+	for i := range layer.Weights {
+		layer.Weights[i] = float32(rng.NormFloat64() * std)
+	}
+	return layer
+}
+
+// Trainer fits a Network to examples by minimizing the squared error of its
+// predictions with Adam.
+type Trainer struct {
+	Network *Network
+
+	LearningRate float64
+	BatchSize    int
+
+	adam *adam
+}
+
+func NewTrainer(n *Network) *Trainer {
+	return &Trainer{Network: n, LearningRate: 1e-3, BatchSize: 256, adam: newAdam(n.Layers)}
+}
+
+// Loss returns the mean squared error of the network's predictions.
+func (t *Trainer) Loss(examples []Example) float64 {
+	loss := 0.0
+	for _, example := range examples {
+		d := t.Network.Predict(example.Active) - example.Target
+		loss += d * d
+	}
+	return loss / float64(len(examples))
+}
+
+// Epoch trains on every example once, in a random order, and returns the mean
+// loss before each update.
+func (t *Trainer) Epoch(examples []Example, rng *rand.Rand) float64 {
+	order := rng.Perm(len(examples))
+
+	loss := 0.0
+	for start := 0; start < len(order); start += t.BatchSize {
+		end := min(start+t.BatchSize, len(order))
+		t.adam.clear()
+		for _, i := range order[start:end] {
+			loss += t.backpropagate(&examples[i])
+		}
+		t.adam.update(t.Network.Layers, t.LearningRate, end-start)
+	}
+
+	return loss / float64(len(examples))
+}
+
+// backpropagate adds the gradient of the squared error of example to the
+// accumulated gradients, and returns the squared error.
+func (t *Trainer) backpropagate(example *Example) float64 {
+	layers := t.Network.Layers
+	hidden, output := layers[:len(layers)-1], &layers[len(layers)-1]
+	gradients := t.adam.gradients
+
+	activations := activate(hidden, example.Active)
+	prediction := math.Tanh(dense(output, activations[len(hidden)-1])[0])
+	diff := prediction - example.Target
+
+	delta := []float64{2 * diff * (1 - prediction*prediction)}
+	delta = backpropagateDense(output, gradients[len(hidden)], activations[len(hidden)-1], delta)
+	backpropagateHidden(hidden, gradients, activations, example.Active, delta)
+
+	return diff * diff
+}
+
+// activate returns the activations of each of layers, each followed by ReLU,
+// where the first reads the indices of the inputs which are one.
+func activate(layers []Layer, active []int) [][]float64 {
+	activations := make([][]float64, len(layers))
+
+	first := &layers[0]
+	h := make([]float64, first.Out)
+	for o, b := range first.Biases {
+		h[o] = float64(b)
+	}
+	for _, i := range active {
+		for o, w := range first.Weights[i*first.Out : (i+1)*first.Out] {
+			h[o] += float64(w)
+		}
+	}
+
+	for l := range layers {
+		if l > 0 {
+			h = dense(&layers[l], activations[l-1])
+		}
+		for o := range h {
+			h[o] = math.Max(h[o], 0)
+		}
+		activations[l] = h
+	}
+	return activations
+}
+
+// dense returns the outputs of layer for in, without activation.
+func dense(layer *Layer, in []float64) []float64 {
+	out := make([]float64, layer.Out)
+	for o, b := range layer.Biases {
+		out[o] = float64(b)
+	}
+	for i, a := range in {
+		if a == 0 {
+			continue
+		}
+		for o, w := range layer.Weights[i*layer.Out : (i+1)*layer.Out] {
+			out[o] += a * float64(w)
+		}
+	}
+	return out
+}
+
+// backpropagateDense adds the gradient of the weights and biases of layer to g,
+// given its input in and the gradient delta of the loss with respect to its
+// outputs. Returns the gradient with respect to in.
+func backpropagateDense(layer *Layer, g []float64, in, delta []float64) []float64 {
+	biases := g[len(layer.Weights):]
+	for o, d := range delta {
+		biases[o] += d
+	}
+
+	next := make([]float64, layer.In)
+	for i, a := range in {
+		if a == 0 {
+			// Inactive ReLU: no gradient flows back through it.
+			continue
+		}
+		row := g[i*layer.Out : (i+1)*layer.Out]
+		weights := layer.Weights[i*layer.Out : (i+1)*layer.Out]
+		for o, d := range delta {
+			row[o] += a * d
+			next[i] += float64(weights[o]) * d
+		}
+	}
+	return next
+}
+
+// backpropagateHidden adds the gradients of layers to gradients, given their
+// activations and the gradient delta of the loss with respect to the
+// activations of the last of them.
+func backpropagateHidden(layers []Layer, gradients, activations [][]float64, active []int, delta []float64) {
+	for l := len(layers) - 1; l >= 0; l-- {
+		for o, a := range activations[l] {
+			if a == 0 {
+				delta[o] = 0
+			}
+		}
+
+		if l > 0 {
+			delta = backpropagateDense(&layers[l], gradients[l], activations[l-1], delta)
+			continue
+		}
+
+		layer := &layers[0]
+		g := gradients[0]
+		biases := g[len(layer.Weights):]
+		for o, d := range delta {
+			biases[o] += d
+		}
+		for _, i := range active {
+			row := g[i*layer.Out : (i+1)*layer.Out]
+			for o, d := range delta {
+				row[o] += d
+			}
+		}
+	}
+}
+
+const (
+	adamBeta1   = 0.9
+	adamBeta2   = 0.999
+	adamEpsilon = 1e-8
+)
+
+// adam accumulates gradients for a list of layers and applies them with Adam.
+type adam struct {
+	step int
+
+	// gradients, m and v hold, for each layer, the weights and then the biases.
+	gradients [][]float64
+	m, v      [][]float64
+}
+
+func newAdam(layers []Layer) *adam {
+	a := &adam{}
+	for _, layer := range layers {
+		size := len(layer.Weights) + len(layer.Biases)
+		a.gradients = append(a.gradients, make([]float64, size))
+		a.m = append(a.m, make([]float64, size))
+		a.v = append(a.v, make([]float64, size))
+	}
+	return a
+}
+
+func (a *adam) clear() {
+	for _, g := range a.gradients {
+		clear(g)
+	}
+}
+
+// update applies the gradients accumulated over a batch of size examples to
+// layers.
+func (a *adam) update(layers []Layer, learningRate float64, size int) {
+	a.step++
+	correction1 := 1 - math.Pow(adamBeta1, float64(a.step))
+	correction2 := 1 - math.Pow(adamBeta2, float64(a.step))
+
+	for l := range layers {
+		layer := &layers[l]
+		g, m, v := a.gradients[l], a.m[l], a.v[l]
+		for i := range g {
+			if g[i] == 0 && m[i] == 0 {
+				// Parameters of inputs which have never been active.
+				continue
+			}
+
+			grad := g[i] / float64(size)
+			m[i] = adamBeta1*m[i] + (1-adamBeta1)*grad
+			v[i] = adamBeta2*v[i] + (1-adamBeta2)*grad*grad
+			step := float32(learningRate * (m[i] / correction1) / (math.Sqrt(v[i]/correction2) + adamEpsilon))
+
+			if i < len(layer.Weights) {
+				layer.Weights[i] -= step
+			} else {
+				layer.Biases[i-len(layer.Weights)] -= step
+			}
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package ttt
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// SearchTree records the positions a Searcher or Solver visits, to a limited
+// depth, for finding out why a search chose a move.
+type SearchTree struct {
+	// MaxDepth is the most plies below the root to record.
+	MaxDepth int
+
+	// Root is the position searched, once a search has run.
+	Root *SearchNode
+
+	// path holds the nodes from the root to the position being searched, with
+	// nil for positions too deep to record.
+	path []*SearchNode
+}
+
+// SearchNode is a position visited by a search. Scores are for Self: the
+// value of the move into the node from a Searcher, or the outcome from a
+// Solver.
+type SearchNode struct {
+	// Move is the move into the position, made by Player. Player is None at
+	// the root.
+	Move   Move
+	Player Player
+
+	Score float64
+
+	// Alpha and Beta are the window the position was searched with. Searcher
+	// searches the moves of the root with the full window.
+	Alpha, Beta float64
+
+	// Cutoff is set if the search of the position's moves stopped early,
+	// because a move was good enough that the rest could not matter.
+	Cutoff bool
+
+	// TTHit is set if the position was found in the transposition table.
+	TTHit bool
+
+	// PV is set on the nodes of the principal variation: the moves each
+	// player would choose from the root, by the scores of the search.
+	PV bool
+
+	Children []*SearchNode
+}
+
+func NewSearchTree(maxDepth int) *SearchTree {
+	return &SearchTree{MaxDepth: maxDepth}
+}
+
+// The methods below do nothing on a nil tree, so that searches can call them
+// without checking whether they are recording.
+
+// reset starts recording a new search.
+func (t *SearchTree) reset() {
+	if t == nil {
+		return
+	}
+	t.Root = &SearchNode{Alpha: math.Inf(-1), Beta: math.Inf(1)}
+	t.path = append(t.path[:0], t.Root)
+}
+
+// current returns the node of the position being searched, or nil if it is
+// not recorded.
+func (t *SearchTree) current() *SearchNode {
+	if t == nil || len(t.path) == 0 {
+		return nil
+	}
+	return t.path[len(t.path)-1]
+}
+
+// enter records that the search is about to search the position after player
+// makes move.
+func (t *SearchTree) enter(move Move, player Player) {
+	if t == nil {
+		return
+	}
+	var node *SearchNode
+	if parent := t.current(); parent != nil && len(t.path) <= t.MaxDepth {
+		node = &SearchNode{Move: move, Player: player, Alpha: math.Inf(-1), Beta: math.Inf(1)}
+		parent.Children = append(parent.Children, node)
+	}
+	t.path = append(t.path, node)
+}
+
+// leave records score for the position being searched, and returns to its
+// parent.
+func (t *SearchTree) leave(score float64) {
+	if node := t.current(); node != nil {
+		node.Score = score
+	}
+	if t != nil {
+		t.path = t.path[:len(t.path)-1]
+	}
+}
+
+// window records the window the position being searched has, for the player
+// to move in it.
+func (t *SearchTree) window(alpha, beta Outcome, toMove Player) {
+	if toMove == Self {
+		t.bounds(float64(alpha), float64(beta))
+	} else {
+		t.bounds(float64(-beta), float64(-alpha))
+	}
+}
+
+// bounds records the window the position being searched has, for Self.
+func (t *SearchTree) bounds(alpha, beta float64) {
+	if node := t.current(); node != nil {
+		node.Alpha, node.Beta = alpha, beta
+	}
+}
+
+// cutoff records that the search of the current position's moves stopped
+// early.
+func (t *SearchTree) cutoff() {
+	if node := t.current(); node != nil {
+		node.Cutoff = true
+	}
+}
+
+// ttHit records that the current position was found in the transposition
+// table.
+func (t *SearchTree) ttHit() {
+	if node := t.current(); node != nil {
+		node.TTHit = true
+	}
+}
+
+// finish records score for the root and marks the principal variation.
+func (t *SearchTree) finish(score float64) {
+	if t == nil {
+		return
+	}
+	t.Root.Score = score
+
+	for node := t.Root; node != nil; {
+		node.PV = true
+		var next *SearchNode
+		for _, child := range node.Children {
+			if next == nil || child.Player == Self && child.Score > next.Score ||
+				child.Player == Opponent && child.Score < next.Score {
+				next = child
+			}
+		}
+		node = next
+	}
+}
+
+// WriteDOT writes the tree as a Graphviz digraph, with the principal variation
+// in bold red. Each node shows its move and score, the window if it was
+// narrowed, and whether it was cut off or found in the transposition table.
+func (t *SearchTree) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	_, _ = fmt.Fprintln(bw, "digraph search {")
+	_, _ = fmt.Fprintln(bw, "\tnode [shape=box, fontname=monospace];")
+
+	id := 0
+	var write func(node *SearchNode) int
+	write = func(node *SearchNode) int {
+		nodeID := id
+		id++
+
+		label := "root"
+		if node.Player != None {
+			label = fmt.Sprintf("%c %s", Symbol(node.Player), node.Move)
+		}
+		label += fmt.Sprintf("\\n%s", formatScore(node.Score))
+		if !math.IsInf(node.Alpha, -1) || !math.IsInf(node.Beta, 1) {
+			label += fmt.Sprintf("\\n[%s, %s]", formatScore(node.Alpha), formatScore(node.Beta))
+		}
+		if node.Cutoff {
+			label += "\\ncutoff"
+		}
+		if node.TTHit {
+			label += "\\nTT hit"
+		}
+
+		style := ""
+		if node.PV {
+			style = ", color=red, penwidth=2"
+		}
+		_, _ = fmt.Fprintf(bw, "\tn%d [label=\"%s\"%s];\n", nodeID, label, style)
+
+		for _, child := range node.Children {
+			childID := write(child)
+			style := ""
+			if node.PV && child.PV {
+				style = " [color=red, penwidth=2]"
+			}
+			_, _ = fmt.Fprintf(bw, "\tn%d -> n%d%s;\n", nodeID, childID, style)
+		}
+		return nodeID
+	}
+	if t.Root != nil {
+		write(t.Root)
+	}
+
+	_, _ = fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "+inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	default:
+		return fmt.Sprintf("%.4g", score)
+	}
+}
+
+// searchNodeJSON is the JSON form of a SearchNode. Infinite scores, which JSON
+// numbers cannot hold, are written as the strings "+inf" and "-inf".
+type searchNodeJSON struct {
+	Move     string        `json:"move,omitempty"`
+	Player   string        `json:"player,omitempty"`
+	Score    any           `json:"score"`
+	Alpha    any           `json:"alpha"`
+	Beta     any           `json:"beta"`
+	Cutoff   bool          `json:"cutoff,omitempty"`
+	TTHit    bool          `json:"tt_hit,omitempty"`
+	PV       bool          `json:"pv,omitempty"`
+	Children []*SearchNode `json:"children,omitempty"`
+}
+
+func (n *SearchNode) MarshalJSON() ([]byte, error) {
+	j := searchNodeJSON{
+		Score:    jsonScore(n.Score),
+		Alpha:    jsonScore(n.Alpha),
+		Beta:     jsonScore(n.Beta),
+		Cutoff:   n.Cutoff,
+		TTHit:    n.TTHit,
+		PV:       n.PV,
+		Children: n.Children,
+	}
+	if n.Player != None {
+		j.Move = n.Move.String()
+		j.Player = string(Symbol(n.Player))
+	}
+	return json.Marshal(j)
+}
+
+func jsonScore(score float64) any {
+	if math.IsInf(score, 0) {
+		return formatScore(score)
+	}
+	return score
+}
@@ -0,0 +1,96 @@
+package ttt_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"ultimate-tic-tac-toe/pkg/ttt"
+)
+
+func TestSearcher_Tree(t *testing.T) {
+	game := ttt.NewGame()
+	moves := make([]ttt.Move, 81)
+	moves = moves[:game.Moves(moves)]
+
+	want := make([]float64, len(moves))
+	ttt.NewSearcher().ScoreMoves(moves, game, 2, want)
+
+	s := ttt.NewSearcher()
+	s.Tree = ttt.NewSearchTree(1)
+	choice := s.PickMove(moves, game, 2)
+
+	root := s.Tree.Root
+	if len(root.Children) != len(moves) {
+		t.Fatalf("got %d children of the root, want %d", len(root.Children), len(moves))
+	}
+	if root.Score != slices.Max(want) {
+		t.Errorf("got root score %v, want %v", root.Score, slices.Max(want))
+	}
+	for i, child := range root.Children {
+		if child.Move != moves[i] || child.Score != want[i] {
+			t.Errorf("got child %v scoring %v, want %v scoring %v", child.Move, child.Score, moves[i], want[i])
+		}
+		if len(child.Children) != 0 {
+			t.Errorf("got %d children of %v below the record depth", len(child.Children), child.Move)
+		}
+		if child.PV != (child.Move == choice) {
+			t.Errorf("got PV %t for %v, choice %v", child.PV, child.Move, choice)
+		}
+	}
+}
+
+func TestSolver_Tree(t *testing.T) {
+	rng := rand.New(rand.NewPCG(4, 0))
+	cutoffs, hits := 0, 0
+	for i := 0; i < 10; i++ {
+		game, moves := lateGame(rng, 12)
+		if game == nil {
+			continue
+		}
+
+		s := ttt.NewSolver()
+		s.Tree = ttt.NewSearchTree(ttt.Cells)
+		outcome, best, _ := s.Solve(moves, game)
+
+		root := s.Tree.Root
+		if root.Score != float64(outcome) {
+			t.Errorf("%d: got root score %v, want %v", i, root.Score, outcome)
+		}
+		pv := root.Children[slices.IndexFunc(root.Children, func(n *ttt.SearchNode) bool { return n.PV })]
+		if pv.Move != best {
+			t.Errorf("%d: got principal variation through %v, want %v", i, pv.Move, best)
+		}
+
+		var count func(node *ttt.SearchNode)
+		count = func(node *ttt.SearchNode) {
+			if node.Cutoff {
+				cutoffs++
+			}
+			if node.TTHit {
+				hits++
+			}
+			for _, child := range node.Children {
+				count(child)
+			}
+		}
+		count(root)
+
+		buf := &bytes.Buffer{}
+		if err := s.Tree.WriteDOT(buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(buf.String(), "digraph") || !strings.Contains(buf.String(), "color=red") {
+			t.Errorf("%d: got DOT without a highlighted principal variation:\n%s", i, buf)
+		}
+		if data, err := json.Marshal(root); err != nil || !json.Valid(data) {
+			t.Errorf("%d: got invalid JSON: %v", i, err)
+		}
+	}
+
+	if cutoffs == 0 || hits == 0 {
+		t.Errorf("got %d cutoffs and %d transposition table hits, want some of each", cutoffs, hits)
+	}
+}
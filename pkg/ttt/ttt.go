@@ -2,8 +2,6 @@ package ttt
 
 import (
 	"fmt"
-	"math"
-	"os"
 )
 
 type Move uint8
@@ -30,7 +28,7 @@ func (m Move) XBoard() uint8 {
 }
 
 func (m Move) YBoard() uint8 {
-	return uint8(m&YBoard) >> 6
+	return uint8(m&YBoard) >> 4
 }
 
 func (m Move) XCell() uint8 {
@@ -50,57 +48,29 @@ const (
 )
 
 type Board struct {
-	Columns   [3]int8
-	Rows      [3]int8
-	Diagonals [2]int8
-	Taken     [3][3]bool
+	// State is the ternary index of the board into the precomputed table of
+	// BoardInfo, with digit 3*x+y holding the player in cell x, y.
+	State uint16
+	Taken [3][3]bool
+
+	// Cells holds the player in each cell.
+	Cells [3][3]Player
 }
 
+// WithMove plays player in cell x, y. Returns true if the move wins the board.
 func (b *Board) WithMove(x, y uint8, player Player) bool {
+	wasWon := boardTable[b.State].Winner != None
 	b.Taken[x][y] = true
+	b.Cells[x][y] = player
+	b.State += digit(player) * pow3[3*x+y]
 
-	win := false
-
-	b.Columns[x] += player
-	if b.Columns[x] == 3 || b.Columns[x] == -3 {
-		win = true
-	}
-
-	b.Rows[y] += player
-	if b.Rows[y] == 3 || b.Rows[y] == -3 {
-		win = true
-	}
-
-	if x == y {
-		b.Diagonals[0] += player
-		if b.Diagonals[0] == 3 || b.Diagonals[0] == -3 {
-			win = true
-		}
-	}
-
-	if x+y == 2 {
-		b.Diagonals[1] += player
-		if b.Diagonals[1] == 3 || b.Diagonals[1] == -3 {
-			win = true
-		}
-	}
-
-	return win
+	return !wasWon && boardTable[b.State].Winner != None
 }
 
 func (b *Board) WithoutMove(x, y uint8, player Player) {
 	b.Taken[x][y] = false
-
-	b.Columns[x] -= player
-	b.Rows[y] -= player
-
-	if x == y {
-		b.Diagonals[0] -= player
-	}
-
-	if x+y == 2 {
-		b.Diagonals[1] -= player
-	}
+	b.Cells[x][y] = None
+	b.State -= digit(player) * pow3[3*x+y]
 }
 
 func (b *Board) LegalMoves(out []Move) int {
@@ -116,196 +86,299 @@ func (b *Board) LegalMoves(out []Move) int {
 	return nMoves
 }
 
+// Winner returns the player with three in a row on b, or None.
+func (b *Board) Winner() Player {
+	return boardTable[b.State].Winner
+}
+
+func (b *Board) Full() bool {
+	return boardTable[b.State].Full
+}
+
+// Anywhere is the forced board when the player to move may play in any board,
+// as on the first move or when sent to a board which is won or full.
+const Anywhere = -1
+
 type Game struct {
 	Boards  [3][3]*Board
 	Winners *Board
+
+	// hash is the Zobrist hash of the cells.
+	hash uint64
+
+	// toMove is the player whose turn it is, and forced the index of the board
+	// they must play in, counting along rows from the top left, or Anywhere.
+	toMove Player
+	forced int
+
+	// open holds, for Self and Opponent by side, the boards they have won or
+	// can still win, as a bitmask with board a, b as bit 3*a+b.
+	open [2]uint16
+
+	// previous holds the forced board before each of the nPrevious moves made
+	// since the turn was last set, for WithoutMove.
+	previous  [Cells]int8
+	nPrevious int
+
+	// history holds the moves made with Play, for Undo, and undone the moves
+	// taken back by Undo since the last new move, last first, for Redo.
+	history []played
+	undone  []Move
+}
+
+// played is a move in the history of a Game, with what is needed to take it
+// back.
+type played struct {
+	move      Move
+	player    Player
+	winsBoard bool
 }
 
+// NewGame returns an empty game with Self to move anywhere.
+func NewGame() *Game {
+	return &Game{
+		Boards:  [3][3]*Board{{{}, {}, {}}, {{}, {}, {}}, {{}, {}, {}}},
+		Winners: &Board{},
+		toMove:  Self,
+		forced:  Anywhere,
+		open:    [2]uint16{allBoards, allBoards},
+	}
+}
+
+// allBoards is the bitmask of every board.
+const allBoards = 1<<9 - 1
+
+// WithMove plays player in cell x, y of the board at a, b, after which the
+// other player is to move in the board at x, y. Returns whether the move won
+// the game and whether it won the board.
 func (g *Game) WithMove(a, b, x, y uint8, player Player) (bool, bool) {
 	boardWinner := g.Boards[a][b].WithMove(x, y, player)
+	g.hash ^= zobristCell(a, b, x, y, player)
 	var gameWinner bool
 	if boardWinner {
-		gameWinner = g.Winners.WithMove(x, y, player)
+		gameWinner = g.Winners.WithMove(a, b, player)
 	}
+	g.reopen(a, b)
 
+	g.previous[g.nPrevious] = int8(g.forced)
+	g.nPrevious++
+	g.toMove = -player
+	g.forced = g.forcedAfter(x, y)
 	return gameWinner, boardWinner
 }
 
+// WithoutMove takes back a move made with WithMove.
 func (g *Game) WithoutMove(a, b, x, y uint8, player Player, wasBoardWin bool) {
 	g.Boards[a][b].WithoutMove(x, y, player)
+	g.hash ^= zobristCell(a, b, x, y, player)
 
 	if wasBoardWin {
-		g.Winners.WithoutMove(x, y, player)
+		g.Winners.WithoutMove(a, b, player)
 	}
-}
+	g.reopen(a, b)
 
-func (g *Game) LegalMoves(x, y uint8, out []Move) int {
-	legalBoards := make([]Move, 9)
-
-	legalBoards[0] = Move((x << 2) + y)
-	nBoards := 1
-
-	if g.Winners.Taken[x][y] {
-		nBoards = g.Winners.LegalMoves(legalBoards)
-	}
-
-	moves := make([]Move, 9)
-	nLegalMoves := 0
-	for i, legalBoard := range legalBoards {
-		a := legalBoard.XCell()
-		b := legalBoard.YCell()
+	g.nPrevious--
+	g.forced = int(g.previous[g.nPrevious])
+	g.previous[g.nPrevious] = 0
+	g.toMove = player
+}
 
-		if i >= nBoards {
-			break
-		}
+// withMove makes move for the player to move with WithMove, leaving the
+// history alone, for searches.
+func (g *Game) withMove(move Move) (bool, bool) {
+	return g.WithMove(move.XBoard(), move.YBoard(), move.XCell(), move.YCell(), g.toMove)
+}
 
-		nMoves := g.Boards[a][b].LegalMoves(moves)
-		for j, move := range moves {
-			if j >= nMoves {
-				break
-			}
-			out[nLegalMoves] = (legalBoard << 4) + move
-			nLegalMoves++
-		}
-	}
+// withoutMove takes back move, the last move made with withMove.
+func (g *Game) withoutMove(move Move, wasBoardWin bool) {
+	g.WithoutMove(move.XBoard(), move.YBoard(), move.XCell(), move.YCell(), -g.toMove, wasBoardWin)
+}
 
-	return nLegalMoves
+// Play makes move for the player to move and adds it to the history, which
+// forgets the moves Redo could make. Returns whether the move won the game and
+// whether it won the board.
+func (g *Game) Play(move Move) (bool, bool) {
+	isWin, winsBoard := g.play(move)
+	g.undone = g.undone[:0]
+	return isWin, winsBoard
 }
 
-func (b *Board) Score() int8 {
-	return b.Columns[0] + b.Columns[1] + b.Columns[2] + b.Rows[0] + b.Rows[1] + b.Rows[2] + b.Diagonals[0] + b.Diagonals[1]
+// play makes move and adds it to the history.
+func (g *Game) play(move Move) (bool, bool) {
+	player := g.toMove
+	isWin, winsBoard := g.withMove(move)
+	g.history = append(g.history, played{move: move, player: player, winsBoard: winsBoard})
+	return isWin, winsBoard
 }
 
-func Minimax(game *Game, depth int, player Player, move Move) float64 {
-	if depth == 0 {
-		var score int16
-		score = int16(game.Winners.Score()) * 100
+// Undo takes back the last move made with Play or Redo, which Redo can make
+// again. Returns false if there is no move to take back.
+func (g *Game) Undo() bool {
+	if len(g.history) == 0 {
+		return false
+	}
 
-		for _, row := range game.Boards {
-			for _, b := range row {
-				score += int16(b.Score())
-			}
-		}
+	last := g.history[len(g.history)-1]
+	g.history = g.history[:len(g.history)-1]
+	m := last.move
+	g.WithoutMove(m.XBoard(), m.YBoard(), m.XCell(), m.YCell(), last.player, last.winsBoard)
+	g.undone = append(g.undone, m)
+	return true
+}
 
-		return float64(score)
+// Redo makes the last move taken back by Undo again, if no other move has
+// been made since. Returns false if there is no move to make again.
+func (g *Game) Redo() bool {
+	if len(g.undone) == 0 {
+		return false
 	}
 
-	var value float64
-	legalMoves := make([]Move, 81)
-	a := move.XCell()
-	b := move.YCell()
-	if player == Self {
-		// Evaluate own moves.
-		value = -100
-		nLegalMoves := game.LegalMoves(a, b, legalMoves)
-		for i, nextMove := range legalMoves {
-			if i >= nLegalMoves {
-				break
-			}
-
-			a := nextMove.XBoard()
-			b := nextMove.YBoard()
-			x := nextMove.XBoard()
-			y := nextMove.YBoard()
+	last := len(g.undone) - 1
+	move := g.undone[last]
+	g.undone = g.undone[:last]
+	g.play(move)
+	return true
+}
 
-			isWin, winsBoard := game.WithMove(a, b, x, y, Self)
-			if isWin {
-				// We can win the game.
-				game.WithoutMove(a, b, x, y, Self, winsBoard)
-				return math.Inf(1.0)
-			}
+// History returns the moves made since the start of the game, or since the
+// turn was last set with SetTurn.
+func (g *Game) History() []Move {
+	moves := make([]Move, len(g.history))
+	for i, p := range g.history {
+		moves[i] = p.move
+	}
+	return moves
+}
 
-			nextMoveValue := Minimax(game, depth-1, Opponent, nextMove)
-			game.WithoutMove(a, b, x, y, Self, winsBoard)
+// ToMove returns the player whose turn it is.
+func (g *Game) ToMove() Player {
+	return g.toMove
+}
 
-			if winsBoard {
-				// We can win a board.
-				nextMoveValue += 1.0
-			}
+// Forced returns the index, counting along rows from the top left, of the board
+// the player to move must play in, or Anywhere.
+func (g *Game) Forced() int {
+	return g.forced
+}
 
-			value = math.Max(value, nextMoveValue)
+// SetTurn sets the player to move and the board they must play in, for games
+// whose boards were changed other than by WithMove. Moves made before cannot
+// be taken back.
+func (g *Game) SetTurn(player Player, forced int) {
+	for a := range uint8(3) {
+		for b := range uint8(3) {
+			g.reopen(a, b)
 		}
-	} else {
-		value = math.Inf(1.0)
-		// Evaluate opponent moves.
-		nLegalMoves := game.LegalMoves(a, b, legalMoves)
-		for i, nextMove := range legalMoves {
-			if i >= nLegalMoves {
-				break
-			}
-
-			a := nextMove.XBoard()
-			b := nextMove.YBoard()
-			x := nextMove.XBoard()
-			y := nextMove.YBoard()
+	}
+	g.toMove = player
+	g.forced = forced
+	g.previous = [Cells]int8{}
+	g.nPrevious = 0
+	g.history = nil
+	g.undone = nil
+}
 
-			isWin, winsBoard := game.WithMove(a, b, x, y, Opponent)
-			if isWin {
-				game.WithoutMove(a, b, x, y, Opponent, winsBoard)
-				// Opponent can win the game.
-				return math.Inf(-1.0)
-			}
+// forcedAfter returns the board the reply to a move in cell x, y must be
+// played in.
+func (g *Game) forcedAfter(x, y uint8) int {
+	if g.decided(x, y) {
+		return Anywhere
+	}
+	return int(y*3 + x)
+}
 
-			nextMoveValue := Minimax(game, depth-1, Self, nextMove)
-			game.WithoutMove(a, b, x, y, Opponent, winsBoard)
+// Moves writes the legal moves of the player to move to out, and returns how
+// many there are.
+func (g *Game) Moves(out []Move) int {
+	return g.movesIn(g.forced, out)
+}
 
-			if winsBoard {
-				// Opponent can win a board.
-				nextMoveValue -= 1.0
-			}
+// movesIn writes the legal moves in the board forced, or in every board which
+// is not won if forced is Anywhere, to out.
+func (g *Game) movesIn(forced int, out []Move) int {
+	if forced != Anywhere {
+		return g.boardMoves(uint8(forced%3), uint8(forced/3), out)
+	}
 
-			if nextMoveValue < value {
-				value = math.Min(value, nextMoveValue)
+	n := 0
+	for a := uint8(0); a < 3; a++ {
+		for b := uint8(0); b < 3; b++ {
+			if !g.Winners.Taken[a][b] {
+				n += g.boardMoves(a, b, out[n:])
 			}
 		}
 	}
-
-	return value
+	return n
 }
 
-func PickMove(moves []Move, nMoves uint8, game *Game, depth int) Move {
-	// Default to first valid move.
-	choice := moves[0]
-
-	value := math.Inf(-1.0)
+// boardMoves writes the moves in the board at a, b to out.
+func (g *Game) boardMoves(a, b uint8, out []Move) int {
+	var cells [9]Move
+	n := g.Boards[a][b].LegalMoves(cells[:])
+	board := ToMove(a, b, 0, 0)
+	for i, cell := range cells[:n] {
+		out[i] = board + cell
+	}
+	return n
+}
 
-	for i, move := range moves {
-		if debug {
-			_, _ = fmt.Fprintf(os.Stderr, "%d/%d: %s", i, len(moves), move)
+// reopen recomputes whether each player has won or can still win the board at
+// a, b.
+func (g *Game) reopen(a, b uint8) {
+	bit := uint16(1) << (3*a + b)
+	for _, player := range []Player{Self, Opponent} {
+		open := g.Winners.Cells[a][b] == player ||
+			!g.Winners.Taken[a][b] && g.Boards[a][b].Info().Open[side(player)]
+		if open {
+			g.open[side(player)] |= bit
+		} else {
+			g.open[side(player)] &^= bit
 		}
+	}
+}
 
-		a := move.XBoard()
-		b := move.YBoard()
-		x := move.XCell()
-		y := move.YCell()
-
-		isWin, winsBoard := game.WithMove(a, b, x, y, Self)
-		if isWin {
-			choice = move
-			value = math.Inf(1.0)
-			game.WithoutMove(a, b, x, y, Self, winsBoard)
-			_, _ = fmt.Fprintln(os.Stderr, "Wins game")
-			break
+// won returns the boards player has won, as a bitmask with board a, b as bit
+// 3*a+b.
+func (g *Game) won(player Player) uint16 {
+	mask := uint16(0)
+	for a, col := range g.Winners.Cells {
+		for b, winner := range col {
+			if winner == player {
+				mask |= 1 << (3*a + b)
+			}
 		}
+	}
+	return mask
+}
 
-		moveValue := Minimax(game, depth-1, Opponent, move)
-		game.WithoutMove(a, b, x, y, Self, winsBoard)
-
-		if winsBoard {
-			moveValue += 1.0
+// CanWin returns true if player has a line of boards which they have won or
+// can still win.
+func (g *Game) CanWin(player Player) bool {
+	open := g.open[side(player)]
+	for _, line := range lineMasks {
+		if open&line == line {
+			return true
 		}
+	}
+	return false
+}
 
-		if moveValue > value {
-			choice = move
-			value = moveValue
-		}
+// Drawn returns true if neither player can win the meta-board any more, even
+// if moves remain.
+func (g *Game) Drawn() bool {
+	return !g.CanWin(Self) && !g.CanWin(Opponent)
+}
 
-		if debug {
-			_, _ = fmt.Fprintf(os.Stderr, ": %f\n", moveValue)
-			if winsBoard {
-				_, _ = fmt.Fprintln(os.Stderr, "Wins board")
-			}
-		}
-	}
-	return choice
+// LegalMoves writes the moves which may reply to a move in cell x, y to out,
+// and returns how many there are. Moves uses the board stored in the game
+// instead.
+func (g *Game) LegalMoves(x, y uint8, out []Move) int {
+	return g.movesIn(g.forcedAfter(x, y), out)
+}
+
+// Score returns the sum over every line of b of its cells, counting Self as 1
+// and Opponent as -1. Dead lines, which have cells of both players, count
+// zero.
+func (b *Board) Score() int8 {
+	return boardTable[b.State].Value
 }
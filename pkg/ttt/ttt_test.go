@@ -2,7 +2,11 @@ package ttt_test
 
 import (
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"math"
+	"math/rand/v2"
+	"reflect"
+	"slices"
 	"testing"
 	"ultimate-tic-tac-toe/pkg/ttt"
 )
@@ -48,10 +52,7 @@ func NewBoard(start *Board) *ttt.Board {
 }
 
 func NewGame(start [3][3]*Board) *ttt.Game {
-	g := &ttt.Game{
-		Boards:  [3][3]*ttt.Board{{{}, {}, {}}, {{}, {}, {}}, {{}, {}, {}}},
-		Winners: &ttt.Board{},
-	}
+	g := ttt.NewGame()
 
 	for xBoard := uint8(0); xBoard < 3; xBoard++ {
 		for yBoard := uint8(0); yBoard < 3; yBoard++ {
@@ -104,7 +105,7 @@ func TestMinimax(t *testing.T) {
 		game     *ttt.Game
 		depth    int
 		player   ttt.Player
-		lastMove ttt.Move
+		forced   int
 		wantEval float64
 	}{
 		{
@@ -128,14 +129,15 @@ func TestMinimax(t *testing.T) {
 			}),
 			depth:    1,
 			player:   ttt.Self,
-			lastMove: ttt.ToMove(0, 0, 0, 0),
+			forced:   0,
 			wantEval: math.Inf(1.0),
 		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			got := ttt.Minimax(tc.game, tc.depth, tc.player, tc.lastMove)
+			tc.game.SetTurn(tc.player, tc.forced)
+			got := ttt.Minimax(tc.game, tc.depth)
 			if got != tc.wantEval {
 				t.Errorf("got %v, want %v", got, tc.wantEval)
 			}
@@ -444,3 +446,220 @@ func TestBoard_WithMove(t *testing.T) {
 		})
 	}
 }
+
+func TestGame_Drawn(t *testing.T) {
+	const X, O = ttt.Self, ttt.Opponent
+
+	tt := []struct {
+		name    string
+		winners Board
+		// dead fills the corner board at 2, 2 with a board neither player can
+		// win, which has one empty cell.
+		dead         bool
+		wantCanWin   [2]bool
+		wantDrawn    bool
+		wantMetaEval float64
+	}{
+		{
+			name:       "start",
+			wantCanWin: [2]bool{true, true},
+		},
+		{
+			name:       "every line has both players",
+			winners:    Board{{X, O, X}, {O, 0, X}, {O, X, O}},
+			wantDrawn:  true,
+			wantCanWin: [2]bool{false, false},
+		},
+		{
+			name:         "open corner",
+			winners:      Board{{X, O, X}, {O, X, X}, {O, X, 0}},
+			wantCanWin:   [2]bool{true, false},
+			wantMetaEval: 4,
+		},
+		{
+			name:       "dead corner",
+			winners:    Board{{X, O, X}, {O, X, X}, {O, X, 0}},
+			dead:       true,
+			wantDrawn:  true,
+			wantCanWin: [2]bool{false, false},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			game := ttt.NewGame()
+			for a := uint8(0); a < 3; a++ {
+				for b := uint8(0); b < 3; b++ {
+					if winner := tc.winners[a][b]; winner != ttt.None {
+						game.Winners.WithMove(a, b, winner)
+					}
+				}
+			}
+			if tc.dead {
+				game.Boards[2][2] = NewBoard(&Board{{X, O, X}, {X, O, O}, {O, X, 0}})
+			}
+			game.SetTurn(X, ttt.Anywhere)
+
+			gotCanWin := [2]bool{game.CanWin(X), game.CanWin(O)}
+			if gotCanWin != tc.wantCanWin {
+				t.Errorf("got can win %v, want %v", gotCanWin, tc.wantCanWin)
+			}
+			if got := game.Drawn(); got != tc.wantDrawn {
+				t.Errorf("got drawn %v, want %v", got, tc.wantDrawn)
+			}
+
+			// Boards in lines neither player can complete count nothing.
+			weights := ttt.Weights{Meta: 1}
+			if got := weights.Evaluate(game); got != tc.wantMetaEval {
+				t.Errorf("got meta evaluation %v, want %v", got, tc.wantMetaEval)
+			}
+		})
+	}
+}
+
+func TestGame_Play(t *testing.T) {
+	game := ttt.NewGame()
+	moves := make([]ttt.Move, 81)
+	if n := game.Moves(moves); n != 81 || game.Forced() != ttt.Anywhere {
+		t.Fatalf("got %d moves in board %d at the start, want 81 anywhere", n, game.Forced())
+	}
+
+	// Opponent wins the top left board, and Self sends them back to it.
+	for _, move := range []ttt.Move{
+		ttt.ToMove(0, 0, 0, 0), ttt.ToMove(0, 0, 1, 0),
+		ttt.ToMove(1, 0, 0, 0), ttt.ToMove(0, 0, 1, 1),
+		ttt.ToMove(1, 1, 0, 0), ttt.ToMove(0, 0, 1, 2),
+		ttt.ToMove(1, 2, 0, 0),
+	} {
+		game.Play(move)
+	}
+	if got := game.ToMove(); got != ttt.Opponent {
+		t.Errorf("got %d to move, want Opponent", got)
+	}
+	if got := game.Forced(); got != ttt.Anywhere {
+		t.Errorf("got forced board %d after sending to a won board, want anywhere", got)
+	}
+
+	last := ttt.ToMove(1, 2, 0, 0)
+	game.Undo()
+	if got := game.ToMove(); got != ttt.Self {
+		t.Errorf("got %d to move after undo, want Self", got)
+	}
+	if got := game.Forced(); got != 7 {
+		t.Errorf("got forced board %d after undo, want 7", got)
+	}
+	n := game.Moves(moves)
+	if n != 9 || !slices.Contains(moves[:n], last) {
+		t.Errorf("got moves %v after undo, want the 9 in the bottom middle board", moves[:n])
+	}
+}
+
+func TestGame_Undo(t *testing.T) {
+	// replay returns a game with moves played from the start.
+	replay := func(moves []ttt.Move) *ttt.Game {
+		game := ttt.NewGame()
+		for _, move := range moves {
+			game.Play(move)
+		}
+		return game
+	}
+	// The redo stack is not part of the position.
+	opts := []cmp.Option{
+		cmp.Exporter(func(reflect.Type) bool { return true }),
+		cmpopts.IgnoreFields(ttt.Game{}, "undone"),
+		cmpopts.EquateEmpty(),
+	}
+
+	for seed := range uint64(10) {
+		rng := rand.New(rand.NewPCG(seed, 0))
+		game := ttt.NewGame()
+		moves := make([]ttt.Move, 81)
+
+		// played and undone model the move stacks of game.
+		var played, undone []ttt.Move
+		over := false
+		for op := 0; op < 200; op++ {
+			n := game.Moves(moves)
+			switch r := rng.IntN(4); {
+			case r == 0:
+				if !game.Undo() {
+					if len(played) != 0 {
+						t.Fatalf("seed %d: got nothing to undo after %v", seed, played)
+					}
+					continue
+				}
+				undone = append(undone, played[len(played)-1])
+				played = played[:len(played)-1]
+				over = false
+			case r == 1:
+				if !game.Redo() {
+					if len(undone) != 0 {
+						t.Fatalf("seed %d: got nothing to redo with %v undone", seed, undone)
+					}
+					continue
+				}
+				move := undone[len(undone)-1]
+				undone = undone[:len(undone)-1]
+				played = append(played, move)
+				over = game.Winners.Info().Winner != ttt.None
+			default:
+				if over || n == 0 {
+					continue
+				}
+				move := moves[rng.IntN(n)]
+				isWin, _ := game.Play(move)
+				played = append(played, move)
+				undone = nil
+				over = isWin
+			}
+
+			if got := game.History(); !slices.Equal(got, played) {
+				t.Fatalf("seed %d: got history %v, want %v", seed, got, played)
+			}
+			// Comparing whole games is slow, so only do so every few operations.
+			if op%10 != 0 {
+				continue
+			}
+			if diff := cmp.Diff(replay(played), game, opts...); diff != "" {
+				t.Fatalf("seed %d: after %v: %s", seed, played, diff)
+			}
+		}
+
+		for game.Undo() {
+		}
+		if diff := cmp.Diff(ttt.NewGame(), game, opts...); diff != "" {
+			t.Errorf("seed %d: got a different game after undoing every move: %s", seed, diff)
+		}
+	}
+}
+
+func TestGame_RedoAfterSearch(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	game := randomGame(rng, 20)
+	want := game.History()
+	if len(want) < 2 {
+		t.Fatalf("got only %d moves", len(want))
+	}
+
+	game.Undo()
+	game.Undo()
+
+	// Searches make and take back moves, which must not touch the history.
+	moves := make([]ttt.Move, 81)
+	n := game.Moves(moves)
+	game.OrderMoves(moves[:n])
+	ttt.NewSearcher().Analyze(moves[:n], game, 2, 0)
+	solver := ttt.NewSolver()
+	solver.MaxNodes = 1000
+	solver.Solve(moves[:n], game)
+	prover := ttt.NewProver()
+	prover.MaxNodes = 1000
+	prover.Prove(moves[:n], game)
+
+	if !game.Redo() || !game.Redo() || game.Redo() {
+		t.Fatal("got a different number of moves to redo after searching, want 2")
+	}
+	if got := game.History(); !slices.Equal(got, want) {
+		t.Errorf("got history %v after redo, want %v", got, want)
+	}
+}